@@ -40,6 +40,74 @@ func BenchmarkNewBUMPFromMerkleTreeAndIndex(b *testing.B) {
 	}
 }
 
+// benchmarkBlockMerkleTree returns the merkle tree of a block of n random txids.
+func benchmarkBlockMerkleTree(b *testing.B, n int) []*chainhash.Hash {
+	b.Helper()
+	chainHashBlock := make([]*chainhash.Hash, 0, n)
+	for i := 0; i < n; i++ {
+		bytes := make([]byte, 32)
+		_, _ = rand.Read(bytes)
+		hash, err := chainhash.NewHash(bytes)
+		if err != nil {
+			b.Fatal(err)
+		}
+		chainHashBlock = append(chainHashBlock, hash)
+	}
+	return BuildMerkleTreeStoreChainHash(chainHashBlock)
+}
+
+// BenchmarkNewBUMPFromMerkleTreeAndIndices benchmarks building one compound BUMP for every
+// tenth transaction of a block of 100,000 txids.
+func BenchmarkNewBUMPFromMerkleTreeAndIndices(b *testing.B) {
+	merkles := benchmarkBlockMerkleTree(b, 100000)
+	indices := make([]uint64, 0, 10000)
+	for idx := uint64(0); idx < 100000; idx += 10 {
+		indices = append(indices, idx)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := NewBUMPFromMerkleTreeAndIndices(850000, merkles, indices)
+		require.NoError(b, err)
+	}
+}
+
+// BenchmarkNewBUMPFromMerkleTreeAndIndexMerged benchmarks building the same compound BUMP as
+// BenchmarkNewBUMPFromMerkleTreeAndIndicesSmall, a BUMP per index merged together, for 200 indices.
+func BenchmarkNewBUMPFromMerkleTreeAndIndexMerged(b *testing.B) {
+	merkles := benchmarkBlockMerkleTree(b, 100000)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		bump, err := NewBUMPFromMerkleTreeAndIndex(850000, merkles, 0)
+		require.NoError(b, err)
+		for idx := uint64(500); idx < 100000; idx += 500 {
+			other, err := NewBUMPFromMerkleTreeAndIndex(850000, merkles, idx)
+			require.NoError(b, err)
+			require.NoError(b, bump.Merge(other))
+		}
+	}
+}
+
+// BenchmarkNewBUMPFromMerkleTreeAndIndicesSmall benchmarks building the compound BUMP of
+// BenchmarkNewBUMPFromMerkleTreeAndIndexMerged in one pass.
+func BenchmarkNewBUMPFromMerkleTreeAndIndicesSmall(b *testing.B) {
+	merkles := benchmarkBlockMerkleTree(b, 100000)
+	indices := make([]uint64, 0, 200)
+	for idx := uint64(0); idx < 100000; idx += 500 {
+		indices = append(indices, idx)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := NewBUMPFromMerkleTreeAndIndices(850000, merkles, indices)
+		require.NoError(b, err)
+	}
+}
+
 // BenchmarkExpandTargetFrom benchmarks the ExpandTargetFrom function
 func BenchmarkExpandTargetFrom(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -4,9 +4,11 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"math/big"
 
 	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
 )
 
@@ -76,18 +78,58 @@ func (bh *BlockHeader) Bytes() []byte {
 	return bytes
 }
 
+// Hash returns the block hash, the double sha256 of the header bytes. Its String
+// method gives the hash in the byte order it is displayed in.
+func (bh *BlockHeader) Hash() chainhash.Hash {
+	return chainhash.Hash(crypto.Sha256d(bh.Bytes()))
+}
+
+// HashStr returns the block hash encoded as hex string, in the byte order
+// it is displayed in.
+func (bh *BlockHeader) HashStr() string {
+	return bh.Hash().String()
+}
+
+// Target returns the target expanded from Bits, which the block hash must not
+// exceed. ErrInvalidBits is returned if Bits is not 4 bytes or the target it
+// encodes is not a positive 256 bit number.
+func (bh *BlockHeader) Target() (*big.Int, error) {
+	if len(bh.Bits) != 4 {
+		return nil, ErrInvalidBits
+	}
+	target, err := ExpandTargetFromAsInt(bh.BitsStr())
+	if err != nil {
+		return nil, err
+	}
+	if target.Sign() <= 0 || target.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBits, bh.BitsStr())
+	}
+	return target, nil
+}
+
+// Work returns the expected number of hashes needed to find a header meeting
+// the target in Bits, 2^256 / (target + 1).
+func (bh *BlockHeader) Work() (*big.Int, error) {
+	target, err := bh.Target()
+	if err != nil {
+		return nil, err
+	}
+	work := new(big.Int).Lsh(big.NewInt(1), 256)
+	return work.Div(work, target.Add(target, big.NewInt(1))), nil
+}
+
 // Valid checks whether a blockheader satisfies the proof-of-work claimed
 // in Bits. Wwe check whether its Hash256 read as a little endian number
 // is less than the Bits written in expanded form.
 func (bh *BlockHeader) Valid() bool {
-	target, err := ExpandTargetFromAsInt(hex.EncodeToString(bh.Bits))
+	target, err := bh.Target()
 	if err != nil {
 		return false
 	}
 
-	digest := bt.ReverseBytes(crypto.Sha256d(bh.Bytes()))
+	hash := bh.Hash()
 	bn := big.NewInt(0)
-	bn.SetBytes(digest)
+	bn.SetBytes(bt.ReverseBytes(hash[:]))
 
 	return bn.Cmp(target) < 0
 }
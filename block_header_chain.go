@@ -22,3 +22,15 @@ var (
 type BlockHeaderChain interface {
 	BlockHeader(ctx context.Context, blockHash string) (*BlockHeader, error)
 }
+
+// A HeightBlockHeaderChain is a BlockHeaderChain which also indexes the longest chain by
+// block height. BUMPs identify their block by height rather than hash, so this is needed
+// to verify them.
+//
+// Lookups for heights which aren't on the longest chain should return ErrHeaderNotFound.
+type HeightBlockHeaderChain interface {
+	BlockHeaderChain
+	BlockHeaderByHeight(ctx context.Context, height uint64) (*BlockHeader, error)
+	ChainTip(ctx context.Context) (*BlockHeader, uint64, error)
+	IsValidRootForHeight(ctx context.Context, merkleRoot string, height uint64) (bool, error)
+}
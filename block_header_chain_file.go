@@ -0,0 +1,123 @@
+package bc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const blockHeaderLen = 80
+
+var _ HeightBlockHeaderChain = (*FileBlockHeaderChain)(nil)
+
+// FileBlockHeaderChain is a MemoryBlockHeaderChain which persists every header
+// it accepts to an append-only file of raw 80-byte headers, so that the chain
+// can be rebuilt when the file is reopened.
+//
+// The first record in the file is always the root header. It is safe for
+// concurrent use, but the file must only be opened by one FileBlockHeaderChain
+// at a time.
+type FileBlockHeaderChain struct {
+	*MemoryBlockHeaderChain
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenFileBlockHeaderChain opens, or creates, the header file at path and replays
+// the headers in it. A new file is started with root, while an existing file must
+// start with root, else ErrHeaderFileCorrupted is returned.
+//
+// A trailing partial record, left behind by an interrupted write, is discarded.
+func OpenFileBlockHeaderChain(path string, root *BlockHeader, rootHeight uint64, opts ...HeaderChainOpt) (*FileBlockHeaderChain, error) {
+	mem, err := NewMemoryBlockHeaderChain(root, rootHeight, opts...)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) //nolint:gosec // path is supplied by the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to open header file: %w", err)
+	}
+	c := &FileBlockHeaderChain{MemoryBlockHeaderChain: mem, f: f}
+	if err = c.load(root); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// load replays the headers held in the file into the in-memory chain, leaving the
+// file positioned at the end of the last complete record.
+func (c *FileBlockHeaderChain) load(root *BlockHeader) error {
+	var (
+		buf [blockHeaderLen]byte
+		n   int64
+	)
+	for ; ; n++ {
+		if _, err := io.ReadFull(c.f, buf[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return fmt.Errorf("failed to read header file: %w", err)
+		}
+		if n == 0 {
+			if !bytes.Equal(buf[:], root.Bytes()) {
+				return ErrHeaderFileCorrupted
+			}
+			continue
+		}
+		bh, err := NewBlockHeaderFromBytes(buf[:])
+		if err != nil {
+			return err
+		}
+		if err = c.MemoryBlockHeaderChain.AddHeader(bh); err != nil {
+			return fmt.Errorf("header %d in file is invalid: %w", n, err)
+		}
+	}
+
+	end := n * blockHeaderLen
+	if err := c.f.Truncate(end); err != nil {
+		return fmt.Errorf("failed to truncate header file: %w", err)
+	}
+	if _, err := c.f.Seek(end, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek header file: %w", err)
+	}
+	if n == 0 {
+		return c.write(root)
+	}
+	return nil
+}
+
+// AddHeader connects bh to the chain as MemoryBlockHeaderChain.AddHeader does, and
+// appends it to the file before it becomes visible to BlockHeader.
+func (c *FileBlockHeaderChain) AddHeader(bh *BlockHeader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.connect(bh)
+	if err != nil || e == nil {
+		return err
+	}
+	if err = c.write(bh); err != nil {
+		return err
+	}
+	c.insert(e)
+	return nil
+}
+
+// Close closes the underlying file. The chain must not be added to afterwards.
+func (c *FileBlockHeaderChain) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.f.Close()
+}
+
+func (c *FileBlockHeaderChain) write(bh *BlockHeader) error {
+	if _, err := c.f.Write(bh.Bytes()); err != nil {
+		return fmt.Errorf("failed to write header file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package bc_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+func TestFileBlockHeaderChain(t *testing.T) {
+	genesis := regtestGenesisHeader(t)
+	ctx := context.Background()
+
+	t.Run("headers persist across reopen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "headers")
+		c, err := bc.OpenFileBlockHeaderChain(path, genesis, 0)
+		require.NoError(t, err)
+		headers := mineChain(t, genesis, 3, 1)
+		for _, bh := range headers {
+			require.NoError(t, c.AddHeader(bh))
+		}
+		require.NoError(t, c.AddHeader(headers[2]))
+		require.NoError(t, c.Close())
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, int64(4*80), fi.Size())
+
+		c, err = bc.OpenFileBlockHeaderChain(path, genesis, 0)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, c.Close()) }()
+		for _, bh := range headers {
+			got, err := c.BlockHeader(ctx, bh.HashStr())
+			require.NoError(t, err)
+			require.Equal(t, bh, got)
+		}
+	})
+
+	t.Run("rejected header is not written", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "headers")
+		c, err := bc.OpenFileBlockHeaderChain(path, genesis, 0)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, c.Close()) }()
+		headers := mineChain(t, genesis, 2, 1)
+		require.ErrorIs(t, c.AddHeader(headers[1]), bc.ErrHeaderOrphan)
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, int64(80), fi.Size())
+	})
+
+	t.Run("partial trailing record is discarded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "headers")
+		bh := mineHeader(t, genesis, 1)
+		data := append(genesis.Bytes(), bh.Bytes()...)
+		require.NoError(t, os.WriteFile(path, append(data, bh.Bytes()[:20]...), 0o600))
+
+		c, err := bc.OpenFileBlockHeaderChain(path, genesis, 0)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, c.Close()) }()
+		_, err = c.BlockHeader(ctx, bh.HashStr())
+		require.NoError(t, err)
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, int64(160), fi.Size())
+	})
+
+	t.Run("file for a different root errors", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "headers")
+		require.NoError(t, os.WriteFile(path, mineHeader(t, genesis, 1).Bytes(), 0o600))
+		_, err := bc.OpenFileBlockHeaderChain(path, genesis, 0)
+		require.ErrorIs(t, err, bc.ErrHeaderFileCorrupted)
+	})
+}
@@ -0,0 +1,300 @@
+package bc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ HeightBlockHeaderChain = (*MemoryBlockHeaderChain)(nil)
+
+// chainEntry is a header which has been connected to the chain, along with the
+// height it sits at and the total work of the chain ending in it.
+type chainEntry struct {
+	header *BlockHeader
+	hash   string
+	height uint64
+	work   ChainWork
+	parent *chainEntry
+}
+
+// MemoryBlockHeaderChain is an in-memory HeightBlockHeaderChain. Headers are added with
+// AddHeader, and must connect to a header already in the chain and satisfy the
+// proof-of-work claimed in their Bits.
+//
+// Every branch is kept, and the branch with the most accumulated work is treated
+// as the longest chain. Headers which are known but are not on it are reported
+// with ErrNotOnLongestChain.
+//
+// It is safe for concurrent use.
+type MemoryBlockHeaderChain struct {
+	mu      sync.RWMutex
+	root    *chainEntry
+	entries map[string]*chainEntry
+	// main holds the longest chain, indexed by height above the root.
+	main []*chainEntry
+	// params are the consensus rules enforced beyond proof-of-work, if set.
+	params *Params
+	// now is the clock header times are checked against when params is set.
+	now func() time.Time
+}
+
+// HeaderChainOpt defines a functional option used to configure a
+// MemoryBlockHeaderChain or FileBlockHeaderChain.
+type HeaderChainOpt func(c *MemoryBlockHeaderChain)
+
+// WithParams makes the chain enforce the consensus rules of a network: headers must
+// not have a target above params.PowLimit, must match any checkpoint at their height,
+// must have the bits required by the difficulty adjustment and must have a time that
+// ValidateHeaderTime accepts. The bits and median-time-past of headers too close to
+// the root for them to be calculated aren't checked.
+func WithParams(params *Params) HeaderChainOpt {
+	return func(c *MemoryBlockHeaderChain) {
+		c.params = params
+	}
+}
+
+// WithClock sets the clock that headers are checked against for being too far in the
+// future when the chain has params. It's time.Now by default.
+func WithClock(now func() time.Time) HeaderChainOpt {
+	return func(c *MemoryBlockHeaderChain) {
+		c.now = now
+	}
+}
+
+// NewMemoryBlockHeaderChain creates a MemoryBlockHeaderChain starting from root,
+// which is trusted without any checks and sits at rootHeight. For a full chain
+// this is the genesis header at height 0, but any checkpoint header can be used.
+func NewMemoryBlockHeaderChain(root *BlockHeader, rootHeight uint64, opts ...HeaderChainOpt) (*MemoryBlockHeaderChain, error) {
+	if root == nil {
+		return nil, ErrHeaderChainNoRoot
+	}
+	work, err := NewChainWork(root)
+	if err != nil {
+		return nil, err
+	}
+	e := &chainEntry{
+		header: root,
+		hash:   root.HashStr(),
+		height: rootHeight,
+		work:   work,
+	}
+
+	c := &MemoryBlockHeaderChain{
+		root:    e,
+		entries: map[string]*chainEntry{e.hash: e},
+		main:    []*chainEntry{e},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// BlockHeader returns the header with the given block hash. ErrHeaderNotFound is
+// returned if the chain doesn't hold it, and ErrNotOnLongestChain if it does but
+// the header is on a stale branch.
+func (c *MemoryBlockHeaderChain) BlockHeader(_ context.Context, blockHash string) (*BlockHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[blockHash]
+	if !ok {
+		return nil, ErrHeaderNotFound
+	}
+	if !c.onMain(e) {
+		return nil, ErrNotOnLongestChain
+	}
+	return e.header, nil
+}
+
+// BlockHeaderByHeight returns the header at height on the longest chain, or
+// ErrHeaderNotFound if the chain doesn't reach that height.
+func (c *MemoryBlockHeaderChain) BlockHeaderByHeight(_ context.Context, height uint64) (*BlockHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e := c.atHeight(height)
+	if e == nil {
+		return nil, ErrHeaderNotFound
+	}
+	return e.header, nil
+}
+
+// ChainTip returns the header at the tip of the longest chain, and its height.
+func (c *MemoryBlockHeaderChain) ChainTip(_ context.Context) (*BlockHeader, uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tip := c.tip()
+	return tip.header, tip.height, nil
+}
+
+// IsValidRootForHeight reports whether merkleRoot, as a hex string, is the merkle root of
+// the header at height on the longest chain. It is false if the chain doesn't reach height.
+func (c *MemoryBlockHeaderChain) IsValidRootForHeight(_ context.Context, merkleRoot string, height uint64) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e := c.atHeight(height)
+	if e == nil {
+		return false, nil
+	}
+	return strings.EqualFold(e.header.HashMerkleRootStr(), merkleRoot), nil
+}
+
+// AddHeader connects bh to the chain, switching the longest chain to it if its
+// branch now has the most accumulated work. Adding a header which is already held
+// is a no-op.
+func (c *MemoryBlockHeaderChain) AddHeader(bh *BlockHeader) error {
+	e, err := c.connect(bh)
+	if err != nil || e == nil {
+		return err
+	}
+	c.insert(e)
+	return nil
+}
+
+// connect validates bh against the chain and returns the entry it would be stored
+// as, without storing it. A nil entry is returned if bh is already held.
+func (c *MemoryBlockHeaderChain) connect(bh *BlockHeader) (*chainEntry, error) {
+	if bh == nil {
+		return nil, ErrInvalidBlockHeaderLength
+	}
+	hash := bh.HashStr()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.entries[hash]; ok {
+		return nil, nil
+	}
+	parent, ok := c.entries[bh.HashPrevBlockStr()]
+	if !ok {
+		return nil, ErrHeaderOrphan
+	}
+	work, err := parent.work.Add(bh)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHeaderInvalidPoW, err)
+	}
+	if !bh.Valid() {
+		return nil, ErrHeaderInvalidPoW
+	}
+	if c.params != nil {
+		if err = c.checkParams(bh, hash, parent); err != nil {
+			return nil, err
+		}
+	}
+
+	return &chainEntry{
+		header: bh,
+		hash:   hash,
+		height: parent.height + 1,
+		work:   work,
+		parent: parent,
+	}, nil
+}
+
+// checkParams checks bh, which would be connected to parent, against c.params.
+func (c *MemoryBlockHeaderChain) checkParams(bh *BlockHeader, hash string, parent *chainEntry) error {
+	height := parent.height + 1
+	target, err := bh.Target()
+	if err != nil {
+		return err
+	}
+	if target.Cmp(c.params.PowLimit) > 0 {
+		return fmt.Errorf("%w: %s", ErrHeaderAbovePowLimit, bh.BitsStr())
+	}
+	if checkpoint, ok := c.params.Checkpoint(height); ok && checkpoint != hash {
+		return fmt.Errorf("%w: expected %s at height %d", ErrHeaderCheckpointMismatch, checkpoint, height)
+	}
+
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	// prev is nil, so only the future limit is checked, when the median-time-past
+	// can't be calculated.
+	prev := c.window(parent, min(medianTimeSpan, height))
+	if err = ValidateHeaderTime(bh, prev, now()); err != nil {
+		return err
+	}
+
+	window := c.window(parent, uint64(c.params.DifficultyWindow(height))) //nolint:gosec // G115: Safe conversion - the window is never negative
+	if window == nil {
+		return nil
+	}
+	bits, err := c.params.ExpectedBits(window, height, bh.Time)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(bits, bh.Bits) {
+		return fmt.Errorf("%w: expected %x at height %d", ErrHeaderBadBits, bits, height)
+	}
+	return nil
+}
+
+// window returns the n headers ending with parent, oldest first, or nil if the chain
+// doesn't hold that many. c.mu must be held.
+func (c *MemoryBlockHeaderChain) window(parent *chainEntry, n uint64) []*BlockHeader {
+	if n > parent.height-c.root.height+1 {
+		return nil
+	}
+	window := make([]*BlockHeader, n)
+	e := parent
+	for i := n; i > 0; i-- {
+		window[i-1] = e.header
+		e = e.parent
+	}
+	return window
+}
+
+// insert stores an entry returned by connect, reorganising the longest chain
+// onto it if it has more work than the current tip.
+func (c *MemoryBlockHeaderChain) insert(e *chainEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[e.hash]; ok {
+		return
+	}
+	c.entries[e.hash] = e
+	if e.work.Cmp(c.tip().work) <= 0 {
+		return
+	}
+
+	// Walk back from the new tip until we meet the current longest chain,
+	// replacing the entries above the fork point.
+	n := int(e.height - c.root.height + 1)
+	if len(c.main) > n {
+		c.main = c.main[:n]
+	}
+	for len(c.main) < n {
+		c.main = append(c.main, nil)
+	}
+	for p := e; !c.onMain(p); p = p.parent {
+		c.main[p.height-c.root.height] = p
+	}
+}
+
+func (c *MemoryBlockHeaderChain) tip() *chainEntry {
+	return c.main[len(c.main)-1]
+}
+
+// atHeight returns the entry at height on the longest chain, or nil if there is
+// none. c.mu must be held.
+func (c *MemoryBlockHeaderChain) atHeight(height uint64) *chainEntry {
+	if height < c.root.height || height-c.root.height >= uint64(len(c.main)) {
+		return nil
+	}
+	return c.main[height-c.root.height]
+}
+
+// onMain reports whether e is on the longest chain. c.mu must be held.
+func (c *MemoryBlockHeaderChain) onMain(e *chainEntry) bool {
+	i := e.height - c.root.height
+	return i < uint64(len(c.main)) && c.main[i] == e
+}
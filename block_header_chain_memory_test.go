@@ -0,0 +1,245 @@
+package bc_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// regtestGenesis is the regtest genesis block header.
+const regtestGenesis = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4adae5494dffff7f2002000000"
+
+// mineHeader returns a regtest header building on prev, distinguished from its
+// siblings by salt.
+func mineHeader(t *testing.T, prev *bc.BlockHeader, salt byte) *bc.BlockHeader {
+	t.Helper()
+	prevHash, err := hex.DecodeString(prev.HashStr())
+	require.NoError(t, err)
+	bh := &bc.BlockHeader{
+		Version:        0x20000000,
+		Time:           prev.Time + 600,
+		HashPrevBlock:  prevHash,
+		HashMerkleRoot: crypto.Sha256d(append([]byte{salt}, prevHash...)),
+		Bits:           []byte{0x20, 0x7f, 0xff, 0xff},
+	}
+	for !bh.Valid() {
+		bh.Nonce++
+	}
+	return bh
+}
+
+// mineChain returns n headers building on prev.
+func mineChain(t *testing.T, prev *bc.BlockHeader, n int, salt byte) []*bc.BlockHeader {
+	t.Helper()
+	headers := make([]*bc.BlockHeader, 0, n)
+	for i := 0; i < n; i++ {
+		prev = mineHeader(t, prev, salt)
+		headers = append(headers, prev)
+	}
+	return headers
+}
+
+func regtestGenesisHeader(t *testing.T) *bc.BlockHeader {
+	t.Helper()
+	genesis, err := bc.NewBlockHeaderFromStr(regtestGenesis)
+	require.NoError(t, err)
+	return genesis
+}
+
+func TestMemoryBlockHeaderChain_AddHeader(t *testing.T) {
+	genesis := regtestGenesisHeader(t)
+	ctx := context.Background()
+
+	t.Run("linked headers are added", func(t *testing.T) {
+		c, err := bc.NewMemoryBlockHeaderChain(genesis, 0)
+		require.NoError(t, err)
+		headers := mineChain(t, genesis, 3, 1)
+		for _, bh := range headers {
+			require.NoError(t, c.AddHeader(bh))
+		}
+		for _, bh := range append(headers, genesis) {
+			got, err := c.BlockHeader(ctx, bh.HashStr())
+			require.NoError(t, err)
+			require.Equal(t, bh, got)
+		}
+
+		// adding a known header again is a no-op
+		require.NoError(t, c.AddHeader(headers[1]))
+	})
+
+	t.Run("unknown header is not found", func(t *testing.T) {
+		c, err := bc.NewMemoryBlockHeaderChain(genesis, 0)
+		require.NoError(t, err)
+		_, err = c.BlockHeader(ctx, mineHeader(t, genesis, 1).HashStr())
+		require.ErrorIs(t, err, bc.ErrHeaderNotFound)
+	})
+
+	t.Run("orphan header is rejected", func(t *testing.T) {
+		c, err := bc.NewMemoryBlockHeaderChain(genesis, 0)
+		require.NoError(t, err)
+		headers := mineChain(t, genesis, 2, 1)
+		require.ErrorIs(t, c.AddHeader(headers[1]), bc.ErrHeaderOrphan)
+	})
+
+	t.Run("header failing proof of work is rejected", func(t *testing.T) {
+		c, err := bc.NewMemoryBlockHeaderChain(genesis, 0)
+		require.NoError(t, err)
+		bh := mineHeader(t, genesis, 1)
+		bh.Bits = []byte{0x1d, 0x00, 0xff, 0xff}
+		require.ErrorIs(t, c.AddHeader(bh), bc.ErrHeaderInvalidPoW)
+	})
+
+	t.Run("nil root errors", func(t *testing.T) {
+		_, err := bc.NewMemoryBlockHeaderChain(nil, 0)
+		require.ErrorIs(t, err, bc.ErrHeaderChainNoRoot)
+	})
+}
+
+func TestMemoryBlockHeaderChain_Reorg(t *testing.T) {
+	genesis := regtestGenesisHeader(t)
+	ctx := context.Background()
+	c, err := bc.NewMemoryBlockHeaderChain(genesis, 0)
+	require.NoError(t, err)
+
+	common := mineHeader(t, genesis, 0)
+	require.NoError(t, c.AddHeader(common))
+	a := mineChain(t, common, 2, 1)
+	b := mineChain(t, common, 3, 2)
+
+	for _, bh := range a {
+		require.NoError(t, c.AddHeader(bh))
+	}
+	// an equal work branch doesn't replace the first seen
+	for _, bh := range b[:2] {
+		require.NoError(t, c.AddHeader(bh))
+	}
+	_, err = c.BlockHeader(ctx, a[1].HashStr())
+	require.NoError(t, err)
+	_, err = c.BlockHeader(ctx, b[1].HashStr())
+	require.ErrorIs(t, err, bc.ErrNotOnLongestChain)
+
+	// more work on b moves the longest chain across
+	require.NoError(t, c.AddHeader(b[2]))
+	for _, bh := range a {
+		_, err = c.BlockHeader(ctx, bh.HashStr())
+		require.ErrorIs(t, err, bc.ErrNotOnLongestChain)
+	}
+	for _, bh := range append(b, common, genesis) {
+		_, err = c.BlockHeader(ctx, bh.HashStr())
+		require.NoError(t, err)
+	}
+}
+
+func TestMemoryBlockHeaderChain_Heights(t *testing.T) {
+	checkpoint := regtestGenesisHeader(t)
+	ctx := context.Background()
+	c, err := bc.NewMemoryBlockHeaderChain(checkpoint, 100)
+	require.NoError(t, err)
+
+	a := mineChain(t, checkpoint, 2, 1)
+	b := mineChain(t, checkpoint, 3, 2)
+	for _, bh := range append(a, b...) {
+		require.NoError(t, c.AddHeader(bh))
+	}
+
+	tip, height, err := c.ChainTip(ctx)
+	require.NoError(t, err)
+	require.Equal(t, b[2], tip)
+	require.Equal(t, uint64(103), height)
+
+	bh, err := c.BlockHeaderByHeight(ctx, 100)
+	require.NoError(t, err)
+	require.Equal(t, checkpoint, bh)
+	for i, exp := range b {
+		bh, err = c.BlockHeaderByHeight(ctx, uint64(101+i))
+		require.NoError(t, err)
+		require.Equal(t, exp, bh)
+	}
+	for _, height := range []uint64{0, 99, 104} {
+		_, err = c.BlockHeaderByHeight(ctx, height)
+		require.ErrorIs(t, err, bc.ErrHeaderNotFound)
+	}
+
+	tests := map[string]struct {
+		root   string
+		height uint64
+		exp    bool
+	}{
+		"root on longest chain is valid": {
+			root:   b[1].HashMerkleRootStr(),
+			height: 102,
+			exp:    true,
+		},
+		"root at wrong height is invalid": {
+			root:   b[1].HashMerkleRootStr(),
+			height: 101,
+		},
+		"root on stale branch is invalid": {
+			root:   a[1].HashMerkleRootStr(),
+			height: 102,
+		},
+		"root above tip is invalid": {
+			root:   b[2].HashMerkleRootStr(),
+			height: 104,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			valid, err := c.IsValidRootForHeight(ctx, test.root, test.height)
+			require.NoError(t, err)
+			require.Equal(t, test.exp, valid)
+		})
+	}
+}
+
+func TestMemoryBlockHeaderChain_WithParams(t *testing.T) {
+	genesis := regtestGenesisHeader(t)
+	h1 := mineHeader(t, genesis, 1)
+
+	t.Run("header above the pow limit", func(t *testing.T) {
+		c, err := bc.NewMemoryBlockHeaderChain(genesis, 0, bc.WithParams(&bc.MainNetParams))
+		require.NoError(t, err)
+		require.ErrorIs(t, c.AddHeader(h1), bc.ErrHeaderAbovePowLimit)
+	})
+
+	t.Run("checkpoint", func(t *testing.T) {
+		params := bc.RegTestParams
+		params.Checkpoints = []bc.Checkpoint{{Height: 1, Hash: h1.HashStr()}}
+		c, err := bc.NewMemoryBlockHeaderChain(genesis, 0, bc.WithParams(&params))
+		require.NoError(t, err)
+
+		require.ErrorIs(t, c.AddHeader(mineHeader(t, genesis, 2)), bc.ErrHeaderCheckpointMismatch)
+		require.NoError(t, c.AddHeader(h1))
+		require.NoError(t, c.AddHeader(mineHeader(t, h1, 2)))
+	})
+}
+
+func TestMemoryBlockHeaderChain_WithParamsDifficulty(t *testing.T) {
+	genesis := regtestGenesisHeader(t)
+	// regtest with the CW-144 difficulty adjustment from the first block.
+	params := bc.RegTestParams
+	params.NoRetargeting = false
+	c, err := bc.NewMemoryBlockHeaderChain(genesis, 0, bc.WithParams(&params))
+	require.NoError(t, err)
+
+	// the first 147 headers are too close to the root to be checked.
+	tip := genesis
+	for _, bh := range mineChain(t, genesis, 147, 1) {
+		require.NoError(t, c.AddHeader(bh))
+		tip = bh
+	}
+
+	harder := mineHeader(t, tip, 2)
+	harder.Bits = []byte{0x20, 0x7f, 0xff, 0xfe}
+	harder.Nonce = 0
+	for !harder.Valid() {
+		harder.Nonce++
+	}
+	require.ErrorIs(t, c.AddHeader(harder), bc.ErrHeaderBadBits)
+	require.NoError(t, c.AddHeader(mineHeader(t, tip, 1)))
+}
@@ -3,12 +3,14 @@ package bc_test
 import (
 	"encoding/hex"
 	"encoding/json"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bsv-blockchain/go-bc"
+	"github.com/bsv-blockchain/go-bc/testing/data"
 )
 
 func TestNewBlockHeader(t *testing.T) {
@@ -244,3 +246,85 @@ func TestBlockHeaderUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+// mainnetGenesis and mainnetBlock1 are the first two mainnet block headers.
+const (
+	mainnetGenesis = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+	mainnetBlock1  = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+)
+
+func TestBlockHeaderHash(t *testing.T) {
+	t.Parallel()
+
+	for _, hash := range fixtureHeaders {
+		b, err := data.BlockHeaderData.Load(hash)
+		require.NoError(t, err)
+		bh, err := bc.NewBlockHeaderFromStr(string(b[:160]))
+		require.NoError(t, err)
+
+		require.Equal(t, hash, bh.HashStr())
+		h := bh.Hash()
+		require.Equal(t, hash, h.String())
+	}
+
+	genesis, err := bc.NewBlockHeaderFromStr(mainnetGenesis)
+	require.NoError(t, err)
+	require.Equal(t, "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f", genesis.HashStr())
+}
+
+func TestBlockHeaderTargetAndWork(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		bits   []byte
+		target string
+		work   int64
+		err    error
+	}{
+		"mainnet genesis": {
+			bits:   []byte{0x1d, 0x00, 0xff, 0xff},
+			target: "ffff0000000000000000000000000000000000000000000000000000",
+			work:   0x100010001,
+		},
+		"regtest": {
+			bits:   []byte{0x20, 0x7f, 0xff, 0xff},
+			target: "7fffff0000000000000000000000000000000000000000000000000000000000",
+			work:   2,
+		},
+		"short bits": {
+			bits: []byte{0x20, 0x7f},
+			err:  bc.ErrInvalidBits,
+		},
+		"negative": {
+			bits: []byte{0x20, 0x80, 0xff, 0xff},
+			err:  bc.ErrInvalidBits,
+		},
+		"zero": {
+			bits: []byte{0x20, 0x00, 0x00, 0x00},
+			err:  bc.ErrInvalidBits,
+		},
+		"overflow": {
+			bits: []byte{0x22, 0x01, 0x00, 0x00},
+			err:  bc.ErrInvalidBits,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bh := &bc.BlockHeader{Bits: test.bits}
+			target, err := bh.Target()
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				_, err = bh.Work()
+				require.ErrorIs(t, err, test.err)
+				require.False(t, bh.Valid())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.target, target.Text(16))
+
+			work, err := bh.Work()
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(test.work), work)
+		})
+	}
+}
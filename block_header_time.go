@@ -0,0 +1,47 @@
+package bc
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// medianTimeSpan is the number of headers median-time-past is taken over.
+	medianTimeSpan = 11
+
+	// MaxFutureBlockTime is how far ahead of the local clock a header's time may be.
+	MaxFutureBlockTime = 2 * time.Hour
+)
+
+// MedianTimePast returns the median-time-past of the block following headers, the
+// median Time of the last 11 of them, or of them all near the start of the chain.
+// headers are ordered oldest first, ending with the parent of the block.
+func MedianTimePast(headers []*BlockHeader) uint32 {
+	if len(headers) > medianTimeSpan {
+		headers = headers[len(headers)-medianTimeSpan:]
+	}
+	if len(headers) == 0 {
+		return 0
+	}
+	times := make([]uint32, 0, len(headers))
+	for _, bh := range headers {
+		times = append(times, bh.Time)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// ValidateHeaderTime checks that the Time of bh is after the median-time-past of the
+// headers preceding it, ErrHeaderTimeTooOld if not, and no more than MaxFutureBlockTime
+// after now, ErrHeaderTimeTooNew if not. prev is ordered oldest first, ending with the
+// parent of bh, and should hold the 11 headers before bh where the chain has them.
+func ValidateHeaderTime(bh *BlockHeader, prev []*BlockHeader, now time.Time) error {
+	if mtp := MedianTimePast(prev); len(prev) > 0 && bh.Time <= mtp {
+		return fmt.Errorf("%w: %d is not after %d", ErrHeaderTimeTooOld, bh.Time, mtp)
+	}
+	if limit := now.Add(MaxFutureBlockTime).Unix(); int64(bh.Time) > limit {
+		return fmt.Errorf("%w: %d is after %d", ErrHeaderTimeTooNew, bh.Time, limit)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package bc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// headersAt returns headers with the given times.
+func headersAt(times ...uint32) []*bc.BlockHeader {
+	headers := make([]*bc.BlockHeader, 0, len(times))
+	for _, t := range times {
+		headers = append(headers, &bc.BlockHeader{Time: t})
+	}
+	return headers
+}
+
+// remine sets the Time of bh and finds a new nonce for it.
+func remine(bh *bc.BlockHeader, t uint32) *bc.BlockHeader {
+	bh.Time = t
+	bh.Nonce = 0
+	for !bh.Valid() {
+		bh.Nonce++
+	}
+	return bh
+}
+
+func TestMedianTimePast(t *testing.T) {
+	tests := map[string]struct {
+		headers []*bc.BlockHeader
+		exp     uint32
+	}{
+		"no headers": {
+			exp: 0,
+		},
+		"one header": {
+			headers: headersAt(5),
+			exp:     5,
+		},
+		"fewer than 11 headers": {
+			headers: headersAt(1, 9, 3, 7),
+			exp:     7,
+		},
+		"unordered times": {
+			headers: headersAt(11, 1, 10, 2, 9, 3, 8, 4, 7, 5, 6),
+			exp:     6,
+		},
+		"only the last 11 headers count": {
+			headers: headersAt(100, 100, 100, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11),
+			exp:     6,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.exp, bc.MedianTimePast(test.headers))
+		})
+	}
+}
+
+func TestValidateHeaderTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	prev := headersAt(1000, 1010, 1020)
+
+	tests := map[string]struct {
+		time   uint32
+		prev   []*bc.BlockHeader
+		expErr error
+	}{
+		"after the median time past": {
+			time: 1011,
+			prev: prev,
+		},
+		"equal to the median time past": {
+			time:   1010,
+			prev:   prev,
+			expErr: bc.ErrHeaderTimeTooOld,
+		},
+		"before the median time past": {
+			time:   1000,
+			prev:   prev,
+			expErr: bc.ErrHeaderTimeTooOld,
+		},
+		"no previous headers": {
+			time: 0,
+		},
+		"at the future limit": {
+			time: uint32(now.Add(bc.MaxFutureBlockTime).Unix()),
+			prev: prev,
+		},
+		"after the future limit": {
+			time:   uint32(now.Add(bc.MaxFutureBlockTime).Unix()) + 1,
+			prev:   prev,
+			expErr: bc.ErrHeaderTimeTooNew,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := bc.ValidateHeaderTime(&bc.BlockHeader{Time: test.time}, test.prev, now)
+			if test.expErr != nil {
+				require.ErrorIs(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestMemoryBlockHeaderChain_WithParamsTime(t *testing.T) {
+	genesis := regtestGenesisHeader(t)
+	headers := mineChain(t, genesis, 11, 1)
+	tip := headers[len(headers)-1]
+	clock := func() time.Time { return time.Unix(int64(tip.Time), 0) }
+
+	c, err := bc.NewMemoryBlockHeaderChain(genesis, 0, bc.WithParams(&bc.RegTestParams), bc.WithClock(clock))
+	require.NoError(t, err)
+	for _, bh := range headers {
+		require.NoError(t, c.AddHeader(bh))
+	}
+
+	// the median-time-past of the tip's children is the time of headers[5].
+	mtp := headers[5].Time
+	require.ErrorIs(t, c.AddHeader(remine(mineHeader(t, tip, 2), mtp)), bc.ErrHeaderTimeTooOld)
+	require.NoError(t, c.AddHeader(remine(mineHeader(t, tip, 3), mtp+1)))
+
+	future := tip.Time + uint32(bc.MaxFutureBlockTime.Seconds()) + 1
+	require.ErrorIs(t, c.AddHeader(remine(mineHeader(t, tip, 4), future)), bc.ErrHeaderTimeTooNew)
+	require.NoError(t, c.AddHeader(remine(mineHeader(t, tip, 5), future-1)))
+}
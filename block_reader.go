@@ -0,0 +1,233 @@
+package bc
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+)
+
+// A BlockReader reads a serialised block from an io.Reader one transaction at a time,
+// so that blocks far larger than memory can be processed.
+//
+// The header and transaction count are read by NewBlockReader, then each transaction
+// is read with NextTx, or hashed without being kept with NextTxID, until they return
+// io.EOF.
+type BlockReader struct {
+	r       io.Reader
+	header  *BlockHeader
+	txCount uint64
+	read    uint64
+}
+
+// NewBlockReader returns a BlockReader of the block in r, having read its header and
+// transaction count.
+//
+// Unless r is a *bufio.Reader it is buffered, so may be read beyond the end of the
+// block. Pass a *bufio.Reader to read a stream of blocks one after another.
+func NewBlockReader(r io.Reader) (*BlockReader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	var b [80]byte
+	if _, err := io.ReadFull(br, b[:]); err != nil {
+		if err == io.EOF {
+			return nil, ErrBlockEmpty
+		}
+		return nil, fmt.Errorf("%w: reading header: %w", ErrReadingBlock, err)
+	}
+	bh, err := NewBlockHeaderFromBytes(b[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var txCount bt.VarInt
+	if _, err = txCount.ReadFrom(br); err != nil {
+		return nil, fmt.Errorf("%w: reading tx count: %w", ErrReadingBlock, err)
+	}
+
+	return &BlockReader{
+		r:       br,
+		header:  bh,
+		txCount: uint64(txCount),
+	}, nil
+}
+
+// Header returns the header of the block.
+func (br *BlockReader) Header() *BlockHeader {
+	return br.header
+}
+
+// TxCount returns the number of transactions in the block, including the coinbase.
+func (br *BlockReader) TxCount() uint64 {
+	return br.txCount
+}
+
+// NextTx reads the next transaction of the block, returning io.EOF once all of them
+// have been read.
+func (br *BlockReader) NextTx() (*bt.Tx, error) {
+	if br.read == br.txCount {
+		return nil, io.EOF
+	}
+	tx := &bt.Tx{}
+	if _, err := tx.ReadFrom(br.r); err != nil {
+		return nil, fmt.Errorf("%w: reading tx %d: %w", ErrReadingBlock, br.read, err)
+	}
+	br.read++
+	return tx, nil
+}
+
+// NextTxID reads the next transaction of the block and returns its id, returning io.EOF
+// once all of them have been read. The transaction is hashed as it is read, so
+// however large it is only a small buffer is used.
+func (br *BlockReader) NextTxID() (*chainhash.Hash, error) {
+	if br.read == br.txCount {
+		return nil, io.EOF
+	}
+	h := sha256.New()
+	if err := skipTx(io.TeeReader(br.r, h)); err != nil {
+		return nil, fmt.Errorf("%w: reading tx %d: %w", ErrReadingBlock, br.read, err)
+	}
+	br.read++
+	txid := chainhash.Hash(sha256.Sum256(h.Sum(nil)))
+	return &txid, nil
+}
+
+// ForEachTx calls fn with each remaining transaction of the block and its index,
+// stopping at the first error.
+func (br *BlockReader) ForEachTx(fn func(index uint64, tx *bt.Tx) error) error {
+	for {
+		index := br.read
+		tx, err := br.NextTx()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(index, tx); err != nil {
+			return err
+		}
+	}
+}
+
+// ForEachTxID calls fn with the id of each remaining transaction of the block and its
+// index, stopping at the first error.
+func (br *BlockReader) ForEachTxID(fn func(index uint64, txid *chainhash.Hash) error) error {
+	for {
+		index := br.read
+		txid, err := br.NextTxID()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(index, txid); err != nil {
+			return err
+		}
+	}
+}
+
+// skipTx reads past a transaction in r, discarding its scripts as they are read.
+func skipTx(r io.Reader) error {
+	// version
+	if err := skip(r, 4); err != nil {
+		return err
+	}
+
+	inputs, err := readVarIntFrom(r)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < inputs; i++ {
+		// previous txid and vout
+		if err = skip(r, 36); err != nil {
+			return err
+		}
+		if err = skipScript(r); err != nil {
+			return err
+		}
+		// sequence
+		if err = skip(r, 4); err != nil {
+			return err
+		}
+	}
+
+	outputs, err := readVarIntFrom(r)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < outputs; i++ {
+		// satoshis
+		if err = skip(r, 8); err != nil {
+			return err
+		}
+		if err = skipScript(r); err != nil {
+			return err
+		}
+	}
+
+	// locktime
+	return skip(r, 4)
+}
+
+// skipScript reads past a script and its length prefix in r.
+func skipScript(r io.Reader) error {
+	length, err := readVarIntFrom(r)
+	if err != nil {
+		return err
+	}
+	return skip(r, length)
+}
+
+// skip reads past n bytes of r.
+func skip(r io.Reader, n uint64) error {
+	if n > 1<<62 {
+		return io.ErrUnexpectedEOF
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil { //nolint:gosec // G115: Safe conversion - n is bounded above
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// readVarIntFrom reads a varint from r.
+func readVarIntFrom(r io.Reader) (uint64, error) {
+	var b [9]byte
+	if _, err := io.ReadFull(r, b[:1]); err != nil {
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	size := 1
+	switch b[0] {
+	case 0xff:
+		size = 9
+	case 0xfe:
+		size = 5
+	case 0xfd:
+		size = 3
+	default:
+		return uint64(b[0]), nil
+	}
+	if _, err := io.ReadFull(r, b[1:size]); err != nil {
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	var buf [8]byte
+	copy(buf[:], b[1:size])
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
@@ -0,0 +1,133 @@
+package bc_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+const readerTestBlock = "000000208340568a93304c2b327d901fde726e26825a753e9d9681697d60f13b5033691540dddb67dc3caf63b5ac5945e62eed5e7b328901c3bad1be775ca773152be5f8023d1561ffff7f20000000000302000000010000000000000000000000000000000000000000000000000000000000000000ffffffff05024e0b0101ffffffff01cc28000000000000232102af5e52d92723981deef3865309f04807a4cb16cc3da8270b203e482c43a370feac00000000020000000372545d8b76a366701abf79c5219a2f70748c2f888e933b82ada34ed070e66d2100000000494830450221009e8c1ec9c0bb567c47e153946c48dbb1c904d892dd149f92721d9fe87b816f1702207584a0fa85d39056a55685e2c7a1ed6f663b995670bc17fefc00b8ed781591d841feffffffef6f13ab6366f7a670869505630fdee12338ef12efbb223e223b44115f3c273100000000484730440220303ebd18633704633c3b92f261173fa833ca0376578e6d54c213d058c42c6716022077ec705a52337011cd7dd86ebcd207e613618b3da1252bae19355ad45cc04acd41feffffffad5cf4c165fde449155b4de8d1eee9f65e9bb66ff7665f4cb4788a38d665adcc010000006b483045022100ac2e344a9ec980b0c2625a5784c17e62ee59b674a146e6268ae56d49016b57e202202e2e7beb60d879148fdb3f0ed98b7b1148780bb31d82794cddc1c4a2f77d1ed5412102b691a69957cf30c1a7ceae9ba719d5f8891662623f0e797146446df73aa83872feffffff02a0860100000000001976a914b85524abf8202a961b847a3bd0bc89d3d4d41cc588acbd440f00000000001976a914fe88c4aeccc229c1bf9913e65fc6ff22f6c9d1fe88ac4d0b000002000000038bf51c82898c0f633f3bab38cdc737a4f666a3640c7128151d6d14bfa911aeb9000000004948304502210095cb2822a8ac066e074a06bf299fd4d2724f869e27e85b02365c2ba54da34e6902202191ffa313b9c4cf55d4893a18e99108d20720bafbbc7c5486238c1e502b254f41feffffffbbba0582b6dc50cce76a0b9d5e00e0cb3afa656db5000eeabad69c3c7b045b860000000049483045022100833865334ae594028a00460dd90575047cdfb9e40d3517051f4841a76035898e0220330e1321e99a59481513978d3fcd34db7b178c8f318176a0eccc0cdb308293a141feffffff5e6584b9ccc112673740ad8fe0f98db8b57585da611a727938fc6702c595827f000000006b483045022100e07f8411e6fd3fdc9ebc9360df6a18a45e49ce80f7e34f387930a16f07d3df6202206eba79ebe9e3760bdb21fa0bb10e4087a51bae88af8b16038d27b89256f9529e412103ba0acf181c9c111451fc5201b8008c33348b49f0b8337e6575312a39eb16852ffeffffff02bd440f00000000001976a914b7a6f23683c5570019094d61429c3c9cbe64533088aca0860100000000001976a914b85524abf8202a961b847a3bd0bc89d3d4d41cc588ac4d0b0000"
+
+func readerTestBlockBytes(t *testing.T) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(readerTestBlock)
+	require.NoError(t, err)
+	return b
+}
+
+func TestBlockReaderNextTx(t *testing.T) {
+	b := readerTestBlockBytes(t)
+	expected, err := bc.NewBlockFromBytes(b)
+	require.NoError(t, err)
+
+	br, err := bc.NewBlockReader(iotest.OneByteReader(bytes.NewReader(b)))
+	require.NoError(t, err)
+	require.Equal(t, expected.BlockHeader, br.Header())
+	require.Equal(t, uint64(len(expected.Txs)), br.TxCount())
+
+	for _, etx := range expected.Txs {
+		tx, err := br.NextTx()
+		require.NoError(t, err)
+		require.Equal(t, etx, tx)
+	}
+	_, err = br.NextTx()
+	require.ErrorIs(t, err, io.EOF)
+	_, err = br.NextTxID()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestBlockReaderNextTxID(t *testing.T) {
+	b := readerTestBlockBytes(t)
+	expected, err := bc.NewBlockFromBytes(b)
+	require.NoError(t, err)
+
+	br, err := bc.NewBlockReader(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	// the first tx is read whole and the rest are only hashed.
+	tx, err := br.NextTx()
+	require.NoError(t, err)
+	require.Equal(t, expected.Txs[0], tx)
+
+	var txids []string
+	require.NoError(t, br.ForEachTxID(func(index uint64, txid *chainhash.Hash) error {
+		require.Equal(t, uint64(len(txids)+1), index)
+		txids = append(txids, txid.String())
+		return nil
+	}))
+	require.Equal(t, []string{expected.Txs[1].TxID(), expected.Txs[2].TxID()}, txids)
+}
+
+func TestBlockReaderForEachTx(t *testing.T) {
+	b := readerTestBlockBytes(t)
+	expected, err := bc.NewBlockFromBytes(b)
+	require.NoError(t, err)
+
+	br, err := bc.NewBlockReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	var txs []*bt.Tx
+	require.NoError(t, br.ForEachTx(func(index uint64, tx *bt.Tx) error {
+		require.Equal(t, uint64(len(txs)), index)
+		txs = append(txs, tx)
+		return nil
+	}))
+	require.Equal(t, expected.Txs, txs)
+
+	errStop := errors.New("stop")
+	br, err = bc.NewBlockReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	calls := 0
+	err = br.ForEachTx(func(uint64, *bt.Tx) error {
+		calls++
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, 1, calls)
+}
+
+func TestBlockReaderStreamOfBlocks(t *testing.T) {
+	b := readerTestBlockBytes(t)
+	r := bufio.NewReader(bytes.NewReader(append(append([]byte{}, b...), b...)))
+
+	for i := 0; i < 2; i++ {
+		br, err := bc.NewBlockReader(r)
+		require.NoError(t, err)
+		require.NoError(t, br.ForEachTxID(func(uint64, *chainhash.Hash) error { return nil }))
+	}
+	_, err := bc.NewBlockReader(r)
+	require.ErrorIs(t, err, bc.ErrBlockEmpty)
+}
+
+func TestBlockReaderTruncated(t *testing.T) {
+	b := readerTestBlockBytes(t)
+
+	_, err := bc.NewBlockReader(bytes.NewReader(nil))
+	require.ErrorIs(t, err, bc.ErrBlockEmpty)
+	_, err = bc.NewBlockReader(bytes.NewReader(b[:79]))
+	require.ErrorIs(t, err, bc.ErrReadingBlock)
+	_, err = bc.NewBlockReader(bytes.NewReader(b[:80]))
+	require.ErrorIs(t, err, bc.ErrReadingBlock)
+
+	for _, cut := range []int{82, 150, len(b) - 1} {
+		br, err := bc.NewBlockReader(bytes.NewReader(b[:cut]))
+		require.NoError(t, err)
+		err = br.ForEachTxID(func(uint64, *chainhash.Hash) error { return nil })
+		require.ErrorIs(t, err, bc.ErrReadingBlock)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+		br, err = bc.NewBlockReader(bytes.NewReader(b[:cut]))
+		require.NoError(t, err)
+		err = br.ForEachTx(func(uint64, *bt.Tx) error { return nil })
+		require.ErrorIs(t, err, bc.ErrReadingBlock)
+	}
+}
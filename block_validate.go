@@ -0,0 +1,147 @@
+package bc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+)
+
+// Validate checks the block is consistent: its header satisfies the proof-of-work
+// claimed in its bits, its only coinbase is the first transaction, no transaction is
+// included twice and the merkle root of its transactions is that of the header.
+//
+// Blocks mutated by duplicating the last transactions of a level of the merkle tree,
+// which have the same merkle root as the block they were copied from (CVE-2012-2459),
+// are rejected with ErrBlockMerkleMutated.
+func (b *Block) Validate() error {
+	if b.BlockHeader == nil {
+		return ErrBlockEmpty
+	}
+	if len(b.Txs) == 0 {
+		return ErrBlockNoTxs
+	}
+	if !b.BlockHeader.Valid() {
+		return ErrHeaderInvalidPoW
+	}
+
+	for i, tx := range b.Txs {
+		if isCoinbase := tx.IsCoinbase(); i == 0 && !isCoinbase {
+			return ErrBlockNoCoinbase
+		} else if i > 0 && isCoinbase {
+			return fmt.Errorf("%w: tx %d", ErrBlockMultipleCoinbases, i)
+		}
+	}
+
+	txids := make([]chainhash.Hash, 0, len(b.Txs))
+	for _, tx := range b.Txs {
+		txids = append(txids, chainhash.Hash(crypto.Sha256d(tx.Bytes())))
+	}
+	root, mutated := merkleRootMutated(txids)
+	if mutated {
+		return ErrBlockMerkleMutated
+	}
+
+	seen := make(map[chainhash.Hash]int, len(txids))
+	for i, txid := range txids {
+		if j, ok := seen[txid]; ok {
+			return fmt.Errorf("%w: %s at %d and %d", ErrBlockDuplicateTx, txid, j, i)
+		}
+		seen[txid] = i
+	}
+
+	if root.String() != b.BlockHeader.HashMerkleRootStr() {
+		return fmt.Errorf("%w: transactions have merkle root %s", ErrBlockMerkleRootMismatch, root)
+	}
+	return nil
+}
+
+// ValidateAtHeight checks the block as Validate does and that its coinbase starts with
+// height, as BIP34 requires of blocks from version 2.
+func (b *Block) ValidateAtHeight(height uint64) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	inputs := b.Txs[0].Inputs
+	if len(inputs) == 0 || inputs[0].UnlockingScript == nil {
+		return ErrBlockBIP34Height
+	}
+	expected := bip34HeightScript(height)
+	if !bytes.HasPrefix(*inputs[0].UnlockingScript, expected) {
+		return fmt.Errorf("%w: expected coinbase to start with %x", ErrBlockBIP34Height, expected)
+	}
+	return nil
+}
+
+// ValidateWithParams checks the block, at height on the network of params, as Validate
+// does, that its target is within params.PowLimit and, from params.BIP34Height, that its
+// coinbase starts with height.
+func (b *Block) ValidateWithParams(params *Params, height uint64) error {
+	var err error
+	if height >= params.BIP34Height {
+		err = b.ValidateAtHeight(height)
+	} else {
+		err = b.Validate()
+	}
+	if err != nil {
+		return err
+	}
+
+	target, err := b.BlockHeader.Target()
+	if err != nil {
+		return err
+	}
+	if target.Cmp(params.PowLimit) > 0 {
+		return fmt.Errorf("%w: %s", ErrHeaderAbovePowLimit, b.BlockHeader.BitsStr())
+	}
+	return nil
+}
+
+// merkleRootMutated returns the merkle root of txids, and whether the two nodes of any
+// pair hashed to compute it are the same, as happens when the last nodes of a level are
+// duplicated to mutate the block without changing its merkle root.
+func merkleRootMutated(txids []chainhash.Hash) (chainhash.Hash, bool) {
+	level := append([]chainhash.Hash(nil), txids...)
+	mutated := false
+	for len(level) > 1 {
+		for i := 0; i+1 < len(level); i += 2 {
+			if level[i] == level[i+1] {
+				mutated = true
+			}
+		}
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := level[:0]
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, chainhash.Hash(crypto.Sha256d(append(level[i][:], level[i+1][:]...))))
+		}
+		level = next
+	}
+	return level[0], mutated
+}
+
+// bip34HeightScript returns the script push of height that BIP34 requires a coinbase
+// to start with, as a minimally encoded script number.
+func bip34HeightScript(height uint64) []byte {
+	switch {
+	case height == 0:
+		// OP_0
+		return []byte{0x00}
+	case height <= 16:
+		// OP_1 to OP_16
+		return []byte{0x50 + byte(height)}
+	}
+
+	var num []byte
+	for h := height; h > 0; h >>= 8 {
+		num = append(num, byte(h))
+	}
+	if num[len(num)-1]&0x80 != 0 {
+		// the top bit is the sign bit, so positive numbers using it need an extra byte.
+		num = append(num, 0x00)
+	}
+	return append([]byte{byte(len(num))}, num...)
+}
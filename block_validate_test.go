@@ -0,0 +1,213 @@
+package bc_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// validateTestCoinbase is a coinbase at height 2892.
+const validateTestCoinbase = "02000000010000000000000000000000000000000000000000000000000000000000000000ffffffff05024c0b0101ffffffff0106270000000000002321033ac208f182e7fe982b1c25027ada05e6fc44590e3f862b0a8422eda03ea5951bac00000000"
+
+// validateTestTxs returns a coinbase followed by n distinct transactions.
+func validateTestTxs(t *testing.T, n int) []*bt.Tx {
+	t.Helper()
+	coinbase, err := bt.NewTxFromString(validateTestCoinbase)
+	require.NoError(t, err)
+	txs := []*bt.Tx{coinbase}
+	for i := 0; i < n; i++ {
+		tx, err := bt.NewTxFromString(validateTestCoinbase)
+		require.NoError(t, err)
+		// spend a made up output so it isn't a coinbase.
+		tx.Inputs[0].PreviousTxOutIndex = 0
+		tx.Inputs[0].SequenceNumber = 0
+		tx.LockTime = uint32(i)
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// minedBlock returns a regtest block of txs with the merkle root of txids.
+func minedBlock(t *testing.T, txs []*bt.Tx, txids []string) *bc.Block {
+	t.Helper()
+	root, err := bc.BuildMerkleRoot(txids)
+	require.NoError(t, err)
+	rootBytes, err := hex.DecodeString(root)
+	require.NoError(t, err)
+	bh := &bc.BlockHeader{
+		Version:        0x20000000,
+		Time:           1700000000,
+		HashPrevBlock:  make([]byte, 32),
+		HashMerkleRoot: rootBytes,
+		Bits:           []byte{0x20, 0x7f, 0xff, 0xff},
+	}
+	for !bh.Valid() {
+		bh.Nonce++
+	}
+	return &bc.Block{BlockHeader: bh, Txs: txs}
+}
+
+func txIDs(txs []*bt.Tx) []string {
+	txids := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		txids = append(txids, tx.TxID())
+	}
+	return txids
+}
+
+func TestBlockValidate(t *testing.T) {
+	t.Run("real block", func(t *testing.T) {
+		b, err := bc.NewBlockFromStr("000000208340568a93304c2b327d901fde726e26825a753e9d9681697d60f13b5033691540dddb67dc3caf63b5ac5945e62eed5e7b328901c3bad1be775ca773152be5f8023d1561ffff7f20000000000302000000010000000000000000000000000000000000000000000000000000000000000000ffffffff05024e0b0101ffffffff01cc28000000000000232102af5e52d92723981deef3865309f04807a4cb16cc3da8270b203e482c43a370feac00000000020000000372545d8b76a366701abf79c5219a2f70748c2f888e933b82ada34ed070e66d2100000000494830450221009e8c1ec9c0bb567c47e153946c48dbb1c904d892dd149f92721d9fe87b816f1702207584a0fa85d39056a55685e2c7a1ed6f663b995670bc17fefc00b8ed781591d841feffffffef6f13ab6366f7a670869505630fdee12338ef12efbb223e223b44115f3c273100000000484730440220303ebd18633704633c3b92f261173fa833ca0376578e6d54c213d058c42c6716022077ec705a52337011cd7dd86ebcd207e613618b3da1252bae19355ad45cc04acd41feffffffad5cf4c165fde449155b4de8d1eee9f65e9bb66ff7665f4cb4788a38d665adcc010000006b483045022100ac2e344a9ec980b0c2625a5784c17e62ee59b674a146e6268ae56d49016b57e202202e2e7beb60d879148fdb3f0ed98b7b1148780bb31d82794cddc1c4a2f77d1ed5412102b691a69957cf30c1a7ceae9ba719d5f8891662623f0e797146446df73aa83872feffffff02a0860100000000001976a914b85524abf8202a961b847a3bd0bc89d3d4d41cc588acbd440f00000000001976a914fe88c4aeccc229c1bf9913e65fc6ff22f6c9d1fe88ac4d0b000002000000038bf51c82898c0f633f3bab38cdc737a4f666a3640c7128151d6d14bfa911aeb9000000004948304502210095cb2822a8ac066e074a06bf299fd4d2724f869e27e85b02365c2ba54da34e6902202191ffa313b9c4cf55d4893a18e99108d20720bafbbc7c5486238c1e502b254f41feffffffbbba0582b6dc50cce76a0b9d5e00e0cb3afa656db5000eeabad69c3c7b045b860000000049483045022100833865334ae594028a00460dd90575047cdfb9e40d3517051f4841a76035898e0220330e1321e99a59481513978d3fcd34db7b178c8f318176a0eccc0cdb308293a141feffffff5e6584b9ccc112673740ad8fe0f98db8b57585da611a727938fc6702c595827f000000006b483045022100e07f8411e6fd3fdc9ebc9360df6a18a45e49ce80f7e34f387930a16f07d3df6202206eba79ebe9e3760bdb21fa0bb10e4087a51bae88af8b16038d27b89256f9529e412103ba0acf181c9c111451fc5201b8008c33348b49f0b8337e6575312a39eb16852ffeffffff02bd440f00000000001976a914b7a6f23683c5570019094d61429c3c9cbe64533088aca0860100000000001976a914b85524abf8202a961b847a3bd0bc89d3d4d41cc588ac4d0b0000")
+		require.NoError(t, err)
+		require.NoError(t, b.Validate())
+		// the coinbase pushes 0x0b4e.
+		require.NoError(t, b.ValidateAtHeight(2894))
+		require.ErrorIs(t, b.ValidateAtHeight(2893), bc.ErrBlockBIP34Height)
+	})
+
+	tests := map[string]struct {
+		block func(t *testing.T) *bc.Block
+		err   error
+	}{
+		"valid": {
+			block: func(t *testing.T) *bc.Block {
+				txs := validateTestTxs(t, 3)
+				return minedBlock(t, txs, txIDs(txs))
+			},
+		},
+		"valid odd number of txs": {
+			block: func(t *testing.T) *bc.Block {
+				txs := validateTestTxs(t, 4)
+				return minedBlock(t, txs, txIDs(txs))
+			},
+		},
+		"no header": {
+			block: func(t *testing.T) *bc.Block {
+				return &bc.Block{Txs: validateTestTxs(t, 1)}
+			},
+			err: bc.ErrBlockEmpty,
+		},
+		"no txs": {
+			block: func(t *testing.T) *bc.Block {
+				return minedBlock(t, nil, []string{validateTestTxs(t, 0)[0].TxID()})
+			},
+			err: bc.ErrBlockNoTxs,
+		},
+		"invalid pow": {
+			block: func(t *testing.T) *bc.Block {
+				txs := validateTestTxs(t, 1)
+				b := minedBlock(t, txs, txIDs(txs))
+				b.BlockHeader.Bits = []byte{0x1d, 0x00, 0xff, 0xff}
+				return b
+			},
+			err: bc.ErrHeaderInvalidPoW,
+		},
+		"first tx not coinbase": {
+			block: func(t *testing.T) *bc.Block {
+				txs := validateTestTxs(t, 2)[1:]
+				return minedBlock(t, txs, txIDs(txs))
+			},
+			err: bc.ErrBlockNoCoinbase,
+		},
+		"second coinbase": {
+			block: func(t *testing.T) *bc.Block {
+				txs := validateTestTxs(t, 2)
+				txs[2].Inputs[0].PreviousTxOutIndex = 0xffffffff
+				txs[2].Inputs[0].SequenceNumber = 0xffffffff
+				return minedBlock(t, txs, txIDs(txs))
+			},
+			err: bc.ErrBlockMultipleCoinbases,
+		},
+		"duplicate tx": {
+			block: func(t *testing.T) *bc.Block {
+				txs := validateTestTxs(t, 3)
+				txs[3] = txs[1]
+				return minedBlock(t, txs, txIDs(txs))
+			},
+			err: bc.ErrBlockDuplicateTx,
+		},
+		"duplicated last tx": {
+			block: func(t *testing.T) *bc.Block {
+				// [cb a b] has the same merkle root as [cb a b b].
+				txs := validateTestTxs(t, 2)
+				b := minedBlock(t, txs, txIDs(txs))
+				b.Txs = append(b.Txs, txs[2])
+				return b
+			},
+			err: bc.ErrBlockMerkleMutated,
+		},
+		"duplicated last pair": {
+			block: func(t *testing.T) *bc.Block {
+				// [cb a b c d e] has the same merkle root as [cb a b c d e d e].
+				txs := validateTestTxs(t, 5)
+				b := minedBlock(t, txs, txIDs(txs))
+				b.Txs = append(b.Txs, txs[4], txs[5])
+				return b
+			},
+			err: bc.ErrBlockMerkleMutated,
+		},
+		"wrong merkle root": {
+			block: func(t *testing.T) *bc.Block {
+				txs := validateTestTxs(t, 3)
+				return minedBlock(t, txs, txIDs(txs[:3]))
+			},
+			err: bc.ErrBlockMerkleRootMismatch,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := test.block(t)
+			if test.err == nil {
+				require.NoError(t, b.Validate())
+				return
+			}
+			require.ErrorIs(t, b.Validate(), test.err)
+		})
+	}
+}
+
+func TestBlockValidateAtHeight(t *testing.T) {
+	tests := map[uint64]string{
+		0:       "00",
+		1:       "51",
+		16:      "60",
+		17:      "0111",
+		127:     "017f",
+		128:     "028000",
+		255:     "02ff00",
+		256:     "020001",
+		2892:    "024c0b",
+		32768:   "03008000",
+		8388607: "03ffff7f",
+		8388608: "0400008000",
+	}
+	for height, push := range tests {
+		script, err := bscript.NewFromHexString(push + "0101")
+		require.NoError(t, err)
+		txs := validateTestTxs(t, 1)
+		txs[0].Inputs[0].UnlockingScript = script
+		b := minedBlock(t, txs, txIDs(txs))
+
+		require.NoErrorf(t, b.ValidateAtHeight(height), "height %d", height)
+		require.ErrorIsf(t, b.ValidateAtHeight(height+1), bc.ErrBlockBIP34Height, "height %d", height)
+	}
+}
+
+func TestBlockValidateWithParams(t *testing.T) {
+	txs := validateTestTxs(t, 1)
+	b := minedBlock(t, txs, txIDs(txs))
+
+	// the coinbase height is only checked from BIP34Height.
+	params := bc.RegTestParams
+	require.NoError(t, b.ValidateWithParams(&params, 100))
+	params.BIP34Height = 100
+	require.ErrorIs(t, b.ValidateWithParams(&params, 100), bc.ErrBlockBIP34Height)
+	require.NoError(t, b.ValidateWithParams(&params, 2892))
+
+	require.ErrorIs(t, b.ValidateWithParams(&bc.MainNetParams, 100), bc.ErrHeaderAbovePowLimit)
+}
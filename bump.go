@@ -27,10 +27,30 @@ type leaf struct {
 	Duplicate *bool   `json:"duplicate,omitempty"`
 }
 
+// BUMPOpt defines a functional option used to limit the resources spent
+// parsing a BUMP from an untrusted source.
+type BUMPOpt func(o *bumpOptions)
+
+type bumpOptions struct {
+	maxLeaves uint64
+}
+
+// WithMaxBUMPLeaves limits the number of leaves, summed across every level, a parsed BUMP
+// may contain. Parsing a BUMP with more leaves returns ErrLimitExceeded. Zero means no limit.
+func WithMaxBUMPLeaves(n uint64) BUMPOpt {
+	return func(o *bumpOptions) {
+		o.maxLeaves = n
+	}
+}
+
 // NewBUMPFromStream takes an array of bytes and contracts a BUMP from it, returning the BUMP
 // and the bytes used. Despite the name, this is not reading a stream in the true sense:
 // it is a byte slice that contains many BUMPs one after another.
-func NewBUMPFromStream(bytes []byte) (*BUMP, int, error) {
+func NewBUMPFromStream(bytes []byte, opts ...BUMPOpt) (*BUMP, int, error) {
+	o := &bumpOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
 	if len(bytes) < 37 {
 		return nil, 0, ErrInsufficientBUMPData
 	}
@@ -38,33 +58,52 @@ func NewBUMPFromStream(bytes []byte) (*BUMP, int, error) {
 
 	// the first bytes are the block height.
 	var skip int
-	index, size := bt.NewVarIntFromBytes(bytes[skip:])
+	index, size, ok := ReadVarInt(bytes, skip)
+	if !ok {
+		return nil, 0, ErrInsufficientBUMPData
+	}
 	skip += size
-	bump.BlockHeight = uint64(index)
+	bump.BlockHeight = index
 
 	// The Next byte is the tree height.
+	if len(bytes) <= skip {
+		return nil, 0, ErrInsufficientBUMPData
+	}
 	treeHeight := uint(bytes[skip])
 	skip++
 
 	// We expect tree height levels.
 	bump.Path = make([][]leaf, treeHeight)
 
+	var totalLeaves uint64
 	for lv := uint(0); lv < treeHeight; lv++ {
 		// For each level we parse a bunch of nLeaves.
-		n, size := bt.NewVarIntFromBytes(bytes[skip:])
+		nLeavesAtThisHeight, size, ok := ReadVarInt(bytes, skip)
+		if !ok {
+			return nil, 0, ErrInsufficientBUMPData
+		}
 		skip += size
-		nLeavesAtThisHeight := uint64(n)
 		if nLeavesAtThisHeight == 0 {
 			return nil, 0, fmt.Errorf("%w: %d", ErrInvalidLeafHeight, lv)
 		}
+		totalLeaves += nLeavesAtThisHeight
+		if o.maxLeaves > 0 && totalLeaves > o.maxLeaves {
+			return nil, 0, fmt.Errorf("%w: more than %d BUMP leaves", ErrLimitExceeded, o.maxLeaves)
+		}
+		// every leaf is at least an offset and a flags byte.
+		if nLeavesAtThisHeight > uint64(len(bytes)-skip)/2 {
+			return nil, 0, ErrInsufficientBUMPData
+		}
 		bump.Path[lv] = make([]leaf, nLeavesAtThisHeight)
 		for lf := uint64(0); lf < nLeavesAtThisHeight; lf++ {
 			// For each leaf we parse the offset, hash, txid and duplicate.
-			offset, size := bt.NewVarIntFromBytes(bytes[skip:])
+			offset, size, ok := ReadVarInt(bytes, skip)
+			if !ok || len(bytes) <= skip+size {
+				return nil, 0, ErrInsufficientBUMPData
+			}
 			skip += size
 			var l leaf
-			o := uint64(offset)
-			l.Offset = &o
+			l.Offset = &offset
 			flags := bytes[skip]
 			skip++
 			dup := flags&1 > 0
@@ -97,8 +136,8 @@ func NewBUMPFromStream(bytes []byte) (*BUMP, int, error) {
 }
 
 // NewBUMPFromBytes creates a new BUMP from a byte slice.
-func NewBUMPFromBytes(bytes []byte) (*BUMP, error) {
-	bump, _, err := NewBUMPFromStream(bytes)
+func NewBUMPFromBytes(bytes []byte, opts ...BUMPOpt) (*BUMP, error) {
+	bump, _, err := NewBUMPFromStream(bytes, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -106,12 +145,12 @@ func NewBUMPFromBytes(bytes []byte) (*BUMP, error) {
 }
 
 // NewBUMPFromStr creates a BUMP from hex string.
-func NewBUMPFromStr(str string) (*BUMP, error) {
+func NewBUMPFromStr(str string, opts ...BUMPOpt) (*BUMP, error) {
 	bytes, err := hex.DecodeString(str)
 	if err != nil {
 		return nil, err
 	}
-	return NewBUMPFromBytes(bytes)
+	return NewBUMPFromBytes(bytes, opts...)
 }
 
 // NewBUMPFromJSON creates a BUMP from a JSON string.
@@ -208,15 +247,21 @@ func (bump *BUMP) CalculateRootGivenTxid(txid string) (string, error) {
 				break
 			}
 		}
-		if !offsetFound {
-			return "", fmt.Errorf("%w: %d", ErrNoHashAtIndex, height)
-		}
 
 		var digest []byte
-		if leafAtThisLevel.Duplicate != nil {
+		if offsetFound && leafAtThisLevel.Duplicate != nil {
 			digest = append(workingHash, workingHash...)
 		} else {
-			leafBytes := BytesFromStringReverse(*leafAtThisLevel.Hash)
+			var leafBytes []byte
+			if offsetFound && leafAtThisLevel.Hash != nil {
+				leafBytes = BytesFromStringReverse(*leafAtThisLevel.Hash)
+			} else if height > 0 {
+				// A compound BUMP omits nodes which can be computed from the level below.
+				leafBytes = bump.derivedHash(height, offset)
+			}
+			if leafBytes == nil {
+				return "", fmt.Errorf("%w: %d", ErrNoHashAtIndex, height)
+			}
 			if (offset % 2) != 0 {
 				digest = append(workingHash, leafBytes...)
 			} else {
@@ -0,0 +1,359 @@
+package bc
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+)
+
+// NewBUMPFromMerkleTreeAndIndices builds one compound BUMP proving every transaction at
+// txIndices, from a merkle tree built by BuildMerkleTreeStoreChainHash. It walks the tree
+// once, so is much quicker than building a BUMP per index and merging them.
+func NewBUMPFromMerkleTreeAndIndices(blockHeight uint64, merkleTree []*chainhash.Hash, txIndices []uint64) (*BUMP, error) {
+	if len(merkleTree) == 0 {
+		return nil, ErrEmptyMerkleTree
+	}
+	if len(txIndices) == 0 {
+		return nil, ErrBUMPNoTxids
+	}
+
+	numOfTxids := (len(merkleTree) + 1) / 2
+	truePointer := true
+	txidLeaves := make(map[uint64]leaf, len(txIndices))
+	indexes := make([]uint64, 0, len(txIndices))
+	for _, txIndex := range txIndices {
+		if txIndex >= uint64(numOfTxids) || merkleTree[txIndex].IsEqual(nil) {
+			return nil, fmt.Errorf("%w: tx index %d", ErrIndexOutOfRange, txIndex)
+		}
+		if _, ok := txidLeaves[txIndex]; ok {
+			continue
+		}
+		offset := txIndex
+		txid := merkleTree[txIndex].String()
+		txidLeaves[txIndex] = leaf{Txid: &truePointer, Hash: &txid, Offset: &offset}
+		indexes = append(indexes, txIndex)
+	}
+
+	bump := &BUMP{BlockHeight: blockHeight}
+	if len(merkleTree) == 1 {
+		// there is no merkle path to calculate
+		bump.Path = [][]leaf{{txidLeaves[indexes[0]]}}
+		return bump, nil
+	}
+
+	treeHeight := int(math.Log2(float64(numOfTxids)))
+	bump.Path = make([][]leaf, treeHeight)
+
+	// siblingLeaf returns the leaf for the node at offset in the level starting at levelOffset.
+	siblingLeaf := func(levelOffset int, offset uint64) leaf {
+		l := leaf{Offset: &offset}
+		hash := merkleTree[levelOffset+int(offset)] //nolint:gosec // G115: Safe conversion - offset is bounded by merkle tree size
+		if hash.IsEqual(nil) {
+			l.Duplicate = &truePointer
+		} else {
+			sh := hash.String()
+			l.Hash = &sh
+		}
+		return l
+	}
+
+	levelOffset := 0
+	numOfHashes := numOfTxids
+	for height := 0; height < treeHeight; height++ {
+		// siblingLeaf can't fail, so neither can levelLeaves.
+		bump.Path[height], _ = levelLeaves(height, indexes, txidLeaves, func(offset uint64) (leaf, error) {
+			return siblingLeaf(levelOffset, offset), nil
+		})
+		levelOffset += numOfHashes
+		numOfHashes >>= 1
+	}
+
+	return bump, nil
+}
+
+// Merge combines other, a BUMP for the same block, into bump so that it proves every txid
+// either of them proves. Leaves are unioned per level by offset, keeping the txid flag from
+// either side, and leaves which can now be computed from the level below are dropped.
+//
+// Both BUMPs must have the same block height and compute the same merkle root, else bump
+// is left untouched and ErrBUMPBlockHeightMismatch or ErrBUMPRootMismatch is returned.
+func (bump *BUMP) Merge(other *BUMP) error {
+	if bump.BlockHeight != other.BlockHeight {
+		return fmt.Errorf("%w: %d and %d", ErrBUMPBlockHeightMismatch, bump.BlockHeight, other.BlockHeight)
+	}
+	if len(bump.Path) != len(other.Path) {
+		return fmt.Errorf("%w: tree heights %d and %d", ErrBUMPRootMismatch, len(bump.Path), len(other.Path))
+	}
+	root, err := bump.root()
+	if err != nil {
+		return err
+	}
+	otherRoot, err := other.root()
+	if err != nil {
+		return err
+	}
+	if root != otherRoot {
+		return fmt.Errorf("%w: %s and %s", ErrBUMPRootMismatch, root, otherRoot)
+	}
+
+	path := make([][]leaf, len(bump.Path))
+	for height := range bump.Path {
+		byOffset := make(map[uint64]leaf, len(bump.Path[height])+len(other.Path[height]))
+		for _, l := range bump.Path[height] {
+			byOffset[*l.Offset] = l
+		}
+		for _, l := range other.Path[height] {
+			existing, ok := byOffset[*l.Offset]
+			if !ok {
+				byOffset[*l.Offset] = l
+				continue
+			}
+			if !sameNode(existing, l) {
+				return fmt.Errorf("%w: leaves differ at level %d offset %d", ErrBUMPRootMismatch, height, *l.Offset)
+			}
+			if existing.Txid == nil && l.Txid != nil {
+				existing.Txid = l.Txid
+				byOffset[*l.Offset] = existing
+			}
+		}
+		path[height] = sortedLeaves(byOffset)
+	}
+
+	bump.Path = pruneDerivable(path)
+	return nil
+}
+
+// Trim returns a new BUMP, for the same block, which proves only the given txids. Leaves only
+// needed to prove other txids are left out, so a compound BUMP can be reduced to the proof a
+// single party needs without the block's full merkle tree.
+//
+// Every txid must be at level 0 of bump, else ErrTxidNotInBUMP is returned.
+func (bump *BUMP) Trim(txids ...string) (*BUMP, error) {
+	if len(txids) == 0 {
+		return nil, ErrBUMPNoTxids
+	}
+	if len(bump.Path) == 0 {
+		return nil, ErrInsufficientBUMPData
+	}
+
+	truePointer := true
+	kept := make(map[uint64]leaf, len(txids))
+	indexes := make([]uint64, 0, len(txids))
+	for _, txid := range txids {
+		l, ok := bump.txidLeaf(txid)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTxidNotInBUMP, txid)
+		}
+		if _, ok = kept[*l.Offset]; ok {
+			continue
+		}
+		l.Txid = &truePointer
+		kept[*l.Offset] = l
+		indexes = append(indexes, *l.Offset)
+	}
+
+	trimmed := &BUMP{
+		BlockHeight: bump.BlockHeight,
+		Path:        make([][]leaf, len(bump.Path)),
+	}
+	if len(bump.Path) == 1 && len(bump.Path[0]) == 1 {
+		// the block only has one transaction, so there is nothing to trim.
+		trimmed.Path[0] = []leaf{kept[indexes[0]]}
+		return trimmed, nil
+	}
+	for height := range bump.Path {
+		leaves, err := levelLeaves(height, indexes, kept, func(offset uint64) (leaf, error) {
+			return bump.siblingLeaf(height, offset)
+		})
+		if err != nil {
+			return nil, err
+		}
+		trimmed.Path[height] = leaves
+	}
+
+	return trimmed, nil
+}
+
+// txidLeaf returns the level 0 leaf holding txid.
+func (bump *BUMP) txidLeaf(txid string) (leaf, bool) {
+	for _, l := range bump.Path[0] {
+		if l.Hash != nil && *l.Hash == txid {
+			return l, true
+		}
+	}
+	return leaf{}, false
+}
+
+// siblingLeaf returns the leaf at offset in level height, without its txid flag, computing
+// it from the level below if the BUMP doesn't hold it.
+func (bump *BUMP) siblingLeaf(height int, offset uint64) (leaf, error) {
+	if l, ok := bump.leafAt(height, offset); ok && (l.Duplicate != nil || l.Hash != nil) {
+		l.Txid = nil
+		return l, nil
+	}
+	h := bump.derivedHash(height, offset)
+	if h == nil {
+		return leaf{}, fmt.Errorf("%w: %d", ErrNoHashAtIndex, height)
+	}
+	hash := StringFromBytesReverse(h)
+	return leaf{Offset: &offset, Hash: &hash}, nil
+}
+
+// root returns the merkle root computed by the BUMP, from a flagged txid if it has one.
+func (bump *BUMP) root() (string, error) {
+	if len(bump.Path) == 0 {
+		return "", ErrInsufficientBUMPData
+	}
+	var txid *string
+	for _, l := range bump.Path[0] {
+		if l.Hash == nil {
+			continue
+		}
+		if l.Txid != nil {
+			txid = l.Hash
+			break
+		}
+		if txid == nil {
+			txid = l.Hash
+		}
+	}
+	if txid == nil {
+		return "", fmt.Errorf("%w: no hashes at level 0", ErrInsufficientBUMPData)
+	}
+	return bump.CalculateRootGivenTxid(*txid)
+}
+
+// derivedHash computes the hash of the node at offset in level height from the level below,
+// returning nil if the BUMP doesn't hold enough leaves to do so.
+func (bump *BUMP) derivedHash(height int, offset uint64) []byte {
+	if height == 0 || height > len(bump.Path) {
+		return nil
+	}
+	left := bump.nodeHash(height-1, offset*2)
+	if left == nil {
+		return nil
+	}
+	right := left
+	if l, ok := bump.leafAt(height-1, offset*2+1); !ok || l.Duplicate == nil {
+		if right = bump.nodeHash(height-1, offset*2+1); right == nil {
+			return nil
+		}
+	}
+	return Sha256Sha256(append(append(make([]byte, 0, 64), left...), right...))
+}
+
+// nodeHash returns the hash of the node at offset in level height, either held in the BUMP
+// or computed from the levels below. Duplicate leaves have no hash of their own so give nil.
+func (bump *BUMP) nodeHash(height int, offset uint64) []byte {
+	if l, ok := bump.leafAt(height, offset); ok {
+		if l.Hash == nil {
+			return nil
+		}
+		return BytesFromStringReverse(*l.Hash)
+	}
+	return bump.derivedHash(height, offset)
+}
+
+// leafAt returns the leaf at offset in level height, if the BUMP holds one.
+func (bump *BUMP) leafAt(height int, offset uint64) (leaf, bool) {
+	for _, l := range bump.Path[height] {
+		if l.Offset != nil && *l.Offset == offset {
+			return l, true
+		}
+	}
+	return leaf{}, false
+}
+
+// pruneDerivable drops the leaves in path which can be computed from both of their children
+// in the level below. A level is left whole if every leaf in it could be dropped, for the
+// same reason levelLeaves always holds a leaf.
+func pruneDerivable(path [][]leaf) [][]leaf {
+	held := make([]map[uint64]bool, len(path))
+	for height, leaves := range path {
+		held[height] = make(map[uint64]bool, len(leaves))
+		for _, l := range leaves {
+			held[height][*l.Offset] = true
+		}
+	}
+	var computable func(height int, offset uint64) bool
+	computable = func(height int, offset uint64) bool {
+		if held[height][offset] {
+			return true
+		}
+		return height > 0 && computable(height-1, offset*2) && computable(height-1, offset*2+1)
+	}
+
+	for height := 1; height < len(path); height++ {
+		kept := make([]leaf, 0, len(path[height]))
+		for _, l := range path[height] {
+			if !computable(height-1, *l.Offset*2) || !computable(height-1, *l.Offset*2+1) {
+				kept = append(kept, l)
+			}
+		}
+		if len(kept) > 0 {
+			path[height] = kept
+		}
+	}
+	return path
+}
+
+// sameNode reports whether a and b, at the same offset, describe the same node.
+func sameNode(a, b leaf) bool {
+	if a.Duplicate != nil || b.Duplicate != nil {
+		return a.Duplicate != nil && b.Duplicate != nil
+	}
+	return a.Hash != nil && b.Hash != nil && *a.Hash == *b.Hash
+}
+
+// levelLeaves returns, sorted by offset, the leaves which level height of a BUMP proving the
+// txs at indexes must hold. These are the txidLeaves at level 0 and the sibling of each
+// ancestor of a proven tx, unless the sibling is an ancestor too, as it's then computed rather
+// than stored. sibling returns the leaf at an offset in the level.
+//
+// BRC-74 requires every level to hold a leaf, so when every sibling is an ancestor the sibling
+// of the first index is held anyway.
+func levelLeaves(height int, indexes []uint64, txidLeaves map[uint64]leaf, sibling func(offset uint64) (leaf, error)) ([]leaf, error) {
+	ancestors := make(map[uint64]bool, len(indexes))
+	for _, idx := range indexes {
+		ancestors[idx>>height] = true
+	}
+	byOffset := make(map[uint64]leaf)
+	if height == 0 {
+		for offset, l := range txidLeaves {
+			byOffset[offset] = l
+		}
+	}
+	for _, idx := range indexes {
+		offset := (idx >> height) ^ 1
+		if ancestors[offset] {
+			continue
+		}
+		l, err := sibling(offset)
+		if err != nil {
+			return nil, err
+		}
+		byOffset[offset] = l
+	}
+	if len(byOffset) == 0 {
+		offset := (indexes[0] >> height) ^ 1
+		l, err := sibling(offset)
+		if err != nil {
+			return nil, err
+		}
+		byOffset[offset] = l
+	}
+	return sortedLeaves(byOffset), nil
+}
+
+func sortedLeaves(byOffset map[uint64]leaf) []leaf {
+	leaves := make([]leaf, 0, len(byOffset))
+	for _, l := range byOffset {
+		leaves = append(leaves, l)
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return *leaves[i].Offset < *leaves[j].Offset
+	})
+	return leaves
+}
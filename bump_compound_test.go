@@ -0,0 +1,249 @@
+package bc
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlock returns the txids of a made up block of size transactions and its merkle tree.
+func testBlock(t *testing.T, size int) ([]*chainhash.Hash, []*chainhash.Hash) {
+	t.Helper()
+	txids := make([]*chainhash.Hash, 0, size)
+	for i := 0; i < size; i++ {
+		var hashBytes [32]byte
+		hashBytes[0] = byte(i + 1)
+		hashBytes[1] = byte(size)
+		hash, err := chainhash.NewHash(hashBytes[:])
+		require.NoError(t, err)
+		txids = append(txids, hash)
+	}
+	return txids, BuildMerkleTreeStoreChainHash(txids)
+}
+
+func offsets(leaves []leaf) []uint64 {
+	o := make([]uint64, 0, len(leaves))
+	for _, l := range leaves {
+		o = append(o, *l.Offset)
+	}
+	return o
+}
+
+func TestBUMPMergeEveryPair(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		txids, merkles := testBlock(t, size)
+		expectedRoot := merkles[len(merkles)-1].String()
+		for i := 0; i < size; i++ {
+			for j := 0; j < size; j++ {
+				bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+				require.NoError(t, err)
+				other, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(j))
+				require.NoError(t, err)
+
+				require.NoErrorf(t, bump.Merge(other), "size=%d i=%d j=%d", size, i, j)
+				require.ElementsMatch(t, uniqueStrings(txids[i].String(), txids[j].String()), bump.Txids())
+				for _, txid := range bump.Txids() {
+					root, err := bump.CalculateRootGivenTxid(txid)
+					require.NoErrorf(t, err, "size=%d i=%d j=%d", size, i, j)
+					require.Equalf(t, expectedRoot, root, "size=%d i=%d j=%d", size, i, j)
+				}
+
+				// the merged BUMP survives a binary round trip.
+				b, err := bump.Bytes()
+				require.NoError(t, err)
+				decoded, err := NewBUMPFromBytes(b)
+				require.NoError(t, err)
+				require.Equal(t, bump, decoded)
+			}
+		}
+	}
+}
+
+func TestBUMPMergeDropsDerivableLeaves(t *testing.T) {
+	txids, merkles := testBlock(t, 8)
+	bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 0)
+	require.NoError(t, err)
+	for _, idx := range []uint64{2, 4} {
+		other, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, idx)
+		require.NoError(t, err)
+		require.NoError(t, bump.Merge(other))
+	}
+
+	require.Equal(t, []uint64{0, 1, 2, 3, 4, 5}, offsets(bump.Path[0]))
+	// nodes 0 and 1 are computed from level 0, only node 3 is needed.
+	require.Equal(t, []uint64{3}, offsets(bump.Path[1]))
+	require.ElementsMatch(t, []string{txids[0].String(), txids[2].String(), txids[4].String()}, bump.Txids())
+	for _, txid := range bump.Txids() {
+		root, err := bump.CalculateRootGivenTxid(txid)
+		require.NoError(t, err)
+		require.Equal(t, merkles[len(merkles)-1].String(), root)
+	}
+}
+
+func TestBUMPMergeErrors(t *testing.T) {
+	_, merkles := testBlock(t, 8)
+	_, otherMerkles := testBlock(t, 7)
+
+	tests := map[string]struct {
+		other  func() *BUMP
+		expErr error
+	}{
+		"different block height": {
+			other: func() *BUMP {
+				b, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum+1, merkles, 3)
+				require.NoError(t, err)
+				return b
+			},
+			expErr: ErrBUMPBlockHeightMismatch,
+		},
+		"different block": {
+			other: func() *BUMP {
+				b, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, otherMerkles, 3)
+				require.NoError(t, err)
+				return b
+			},
+			expErr: ErrBUMPRootMismatch,
+		},
+		"different tree height": {
+			other: func() *BUMP {
+				b, err := NewBUMPFromStr(hexExample)
+				require.NoError(t, err)
+				return b
+			},
+			expErr: ErrBUMPRootMismatch,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 0)
+			require.NoError(t, err)
+			before, err := bump.String()
+			require.NoError(t, err)
+
+			require.ErrorIs(t, bump.Merge(test.other()), test.expErr)
+			after, err := bump.String()
+			require.NoError(t, err)
+			require.Equal(t, before, after)
+		})
+	}
+}
+
+func uniqueStrings(s ...string) []string {
+	seen := make(map[string]bool, len(s))
+	out := make([]string, 0, len(s))
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func TestBUMPTrim(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		txids, merkles := testBlock(t, size)
+		expectedRoot := merkles[len(merkles)-1].String()
+
+		compound, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 0)
+		require.NoError(t, err)
+		for i := 1; i < size; i++ {
+			other, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+			require.NoError(t, err)
+			require.NoError(t, compound.Merge(other))
+		}
+
+		for i := 0; i < size; i++ {
+			// trimming to one txid gives the same BUMP as building it from the merkle tree.
+			single, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+			require.NoError(t, err)
+			trimmed, err := compound.Trim(txids[i].String())
+			require.NoErrorf(t, err, "size=%d i=%d", size, i)
+			require.Equalf(t, single, trimmed, "size=%d i=%d", size, i)
+
+			for j := i + 1; j < size; j++ {
+				trimmed, err = compound.Trim(txids[i].String(), txids[j].String())
+				require.NoErrorf(t, err, "size=%d i=%d j=%d", size, i, j)
+				require.ElementsMatch(t, []string{txids[i].String(), txids[j].String()}, trimmed.Txids())
+				for _, txid := range trimmed.Txids() {
+					root, err := trimmed.CalculateRootGivenTxid(txid)
+					require.NoError(t, err)
+					require.Equal(t, expectedRoot, root)
+				}
+				b, err := trimmed.Bytes()
+				require.NoError(t, err)
+				_, err = NewBUMPFromBytes(b)
+				require.NoError(t, err)
+			}
+		}
+	}
+}
+
+func TestBUMPTrimErrors(t *testing.T) {
+	bump, err := NewBUMPFromStr(hexExample)
+	require.NoError(t, err)
+
+	_, err = bump.Trim()
+	require.ErrorIs(t, err, ErrBUMPNoTxids)
+
+	_, err = bump.Trim(txidExample, txidSmallBlock)
+	require.ErrorIs(t, err, ErrTxidNotInBUMP)
+
+	trimmed, err := bump.Trim(txidExample)
+	require.NoError(t, err)
+	require.Equal(t, bump, trimmed)
+}
+
+func TestNewBUMPFromMerkleTreeAndIndices(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		txids, merkles := testBlock(t, size)
+
+		compound, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 0)
+		require.NoError(t, err)
+		for i := 1; i < size; i++ {
+			other, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+			require.NoError(t, err)
+			require.NoError(t, compound.Merge(other))
+		}
+
+		for i := 0; i < size; i++ {
+			single, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+			require.NoError(t, err)
+			bump, err := NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, []uint64{uint64(i)})
+			require.NoError(t, err)
+			require.Equalf(t, single, bump, "size=%d i=%d", size, i)
+
+			// every run of indices from i gives the same BUMP as trimming the full compound.
+			indices := []uint64{}
+			subset := []string{}
+			for j := i; j < size; j += 1 + j%3 {
+				indices = append(indices, uint64(j))
+				subset = append(subset, txids[j].String())
+			}
+			bump, err = NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, indices)
+			require.NoError(t, err)
+			trimmed, err := compound.Trim(subset...)
+			require.NoError(t, err)
+			require.Equalf(t, trimmed, bump, "size=%d indices=%v", size, indices)
+			require.NoError(t, bump.Validate())
+		}
+	}
+}
+
+func TestNewBUMPFromMerkleTreeAndIndicesErrors(t *testing.T) {
+	_, merkles := testBlock(t, 5)
+
+	_, err := NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, nil, []uint64{0})
+	require.ErrorIs(t, err, ErrEmptyMerkleTree)
+
+	_, err = NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, nil)
+	require.ErrorIs(t, err, ErrBUMPNoTxids)
+
+	// the tree is padded to 8 leaves, but index 5 onwards aren't transactions.
+	_, err = NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, []uint64{1, 5})
+	require.ErrorIs(t, err, ErrIndexOutOfRange)
+
+	_, err = NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, []uint64{8})
+	require.ErrorIs(t, err, ErrIndexOutOfRange)
+}
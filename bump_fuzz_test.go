@@ -0,0 +1,25 @@
+package bc
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// FuzzNewBUMPFromStream ensures NewBUMPFromStream never panics on malformed input
+// and never reports using more bytes than it was given.
+func FuzzNewBUMPFromStream(f *testing.F) {
+	for _, s := range []string{hexExample, testnetHexExample, twoTxExample, oneTxidHeightOne} {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, size, err := NewBUMPFromStream(b)
+		if err == nil && size > len(b) {
+			t.Fatalf("used %d bytes of %d", size, len(b))
+		}
+	})
+}
@@ -151,6 +151,43 @@ func TestNotEnoughLeavesInHeight(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestBUMPLeafCountBeyondData(t *testing.T) {
+	// a level claiming 2^64-1 leaves must fail rather than allocate them.
+	_, err := NewBUMPFromStr("0101ffffffffffffffffff026d053ad9c0dec4f973cff9273394c41f3801e7d80e85964fe211d3fdee772f91")
+	require.ErrorIs(t, err, ErrInsufficientBUMPData)
+}
+
+func TestNewBUMPWithMaxLeaves(t *testing.T) {
+	tests := map[string]struct {
+		max    uint64
+		expErr error
+	}{
+		"no limit": {
+			max: 0,
+		},
+		"limit at leaf count": {
+			max: 8,
+		},
+		"limit below leaf count": {
+			max:    7,
+			expErr: ErrLimitExceeded,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bump, err := NewBUMPFromStr(hexExample, WithMaxBUMPLeaves(test.max))
+			if test.expErr != nil {
+				require.ErrorIs(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			root, err := bump.CalculateRootGivenTxid(txidExample)
+			require.NoError(t, err)
+			require.Equal(t, rootExample, root)
+		})
+	}
+}
+
 func TestTxIDs(t *testing.T) {
 	chainHashBlock := make([]*chainhash.Hash, 0, len(testnetBlockExample))
 	for _, txid := range testnetBlockExample {
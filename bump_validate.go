@@ -0,0 +1,82 @@
+package bc
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// Validate checks the BUMP against the BRC-74 invariants, without needing the block header
+// chain. Every level must hold at least one leaf, each leaf must have an offset within the
+// width of its level which no other leaf in the level shares, and either a 32-byte hash or
+// the duplicate flag. Only level 0 leaves may be flagged as txids, duplicates must be the
+// right hand node of a pair, and every flagged txid must compute the same merkle root.
+//
+// The errors returned name the level and offset of the offending leaf.
+func (bump *BUMP) Validate() error {
+	if len(bump.Path) == 0 {
+		return ErrInsufficientBUMPData
+	}
+	for height, leaves := range bump.Path {
+		if len(leaves) == 0 {
+			return fmt.Errorf("%w: %d", ErrInvalidLeafHeight, height)
+		}
+		seen := make(map[uint64]bool, len(leaves))
+		for _, l := range leaves {
+			if l.Offset == nil {
+				return fmt.Errorf("%w: level %d", ErrBUMPLeafNoOffset, height)
+			}
+			if err := bump.validateLeaf(height, l); err != nil {
+				return fmt.Errorf("%w: level %d offset %d", err, height, *l.Offset)
+			}
+			if seen[*l.Offset] {
+				return fmt.Errorf("%w: level %d offset %d", ErrBUMPDuplicateOffset, height, *l.Offset)
+			}
+			seen[*l.Offset] = true
+		}
+	}
+
+	root, err := bump.root()
+	if err != nil {
+		return err
+	}
+	for _, l := range bump.Path[0] {
+		if l.Txid == nil {
+			continue
+		}
+		txidRoot, err := bump.CalculateRootGivenTxid(*l.Hash)
+		if err != nil {
+			return fmt.Errorf("%w: level 0 offset %d", err, *l.Offset)
+		}
+		if txidRoot != root {
+			return fmt.Errorf("%w: level 0 offset %d", ErrBUMPRootMismatch, *l.Offset)
+		}
+	}
+	return nil
+}
+
+// validateLeaf checks the leaf l, found at level height, on its own.
+func (bump *BUMP) validateLeaf(height int, l leaf) error {
+	// a tree with len(bump.Path) levels has at most 2^(len(bump.Path)-height) nodes at height.
+	if width := len(bump.Path) - height; width < 64 && *l.Offset >= uint64(1)<<uint(width) {
+		return ErrBUMPOffsetOutOfRange
+	}
+	if l.Txid != nil && height != 0 {
+		return ErrBUMPTxidNotAtLevelZero
+	}
+	if l.Duplicate != nil {
+		if l.Hash != nil || l.Txid != nil {
+			return ErrBUMPInvalidDuplicate
+		}
+		if *l.Offset%2 == 0 {
+			return ErrBUMPInvalidDuplicate
+		}
+		return nil
+	}
+	if l.Hash == nil {
+		return ErrBUMPLeafNoHash
+	}
+	if h, err := hex.DecodeString(*l.Hash); err != nil || len(h) != 32 {
+		return ErrBUMPLeafNoHash
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package bc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBUMPValidate(t *testing.T) {
+	truePointer := true
+	tests := map[string]struct {
+		mutate func(b *BUMP)
+		expErr error
+	}{
+		"valid BUMP passes": {
+			mutate: func(_ *BUMP) {},
+		},
+		"empty path fails": {
+			mutate: func(b *BUMP) { b.Path = nil },
+			expErr: ErrInsufficientBUMPData,
+		},
+		"empty level fails": {
+			mutate: func(b *BUMP) { b.Path[3] = []leaf{} },
+			expErr: ErrInvalidLeafHeight,
+		},
+		"leaf without offset fails": {
+			mutate: func(b *BUMP) { b.Path[1][0].Offset = nil },
+			expErr: ErrBUMPLeafNoOffset,
+		},
+		"duplicate offsets fail": {
+			mutate: func(b *BUMP) { b.Path[2] = append(b.Path[2], b.Path[2][0]) },
+			expErr: ErrBUMPDuplicateOffset,
+		},
+		"offset beyond the level width fails": {
+			mutate: func(b *BUMP) {
+				offset := uint64(2)
+				b.Path[6][0].Offset = &offset
+			},
+			expErr: ErrBUMPOffsetOutOfRange,
+		},
+		"txid flag above level 0 fails": {
+			mutate: func(b *BUMP) { b.Path[1][0].Txid = &truePointer },
+			expErr: ErrBUMPTxidNotAtLevelZero,
+		},
+		"duplicate at a left hand offset fails": {
+			mutate: func(b *BUMP) {
+				b.Path[2][0].Hash = nil
+				b.Path[2][0].Duplicate = &truePointer
+			},
+			expErr: ErrBUMPInvalidDuplicate,
+		},
+		"duplicate with a hash fails": {
+			mutate: func(b *BUMP) { b.Path[1][0].Duplicate = &truePointer },
+			expErr: ErrBUMPInvalidDuplicate,
+		},
+		"leaf without a hash fails": {
+			mutate: func(b *BUMP) { b.Path[1][0].Hash = nil },
+			expErr: ErrBUMPLeafNoHash,
+		},
+		"leaf with a short hash fails": {
+			mutate: func(b *BUMP) {
+				h := "abcd"
+				b.Path[1][0].Hash = &h
+			},
+			expErr: ErrBUMPLeafNoHash,
+		},
+		"flagged txid which cannot compute a root fails": {
+			mutate: func(b *BUMP) {
+				offset := uint64(40)
+				b.Path[0] = append(b.Path[0], leaf{Offset: &offset, Hash: b.Path[1][0].Hash, Txid: &truePointer})
+			},
+			expErr: ErrNoHashAtIndex,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bump, err := NewBUMPFromStr(hexExample)
+			require.NoError(t, err)
+			test.mutate(bump)
+			err = bump.Validate()
+			if test.expErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, test.expErr)
+		})
+	}
+}
+
+func TestBUMPValidateCompound(t *testing.T) {
+	for size := 1; size <= 17; size++ {
+		_, merkles := testBlock(t, size)
+		compound, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 0)
+		require.NoError(t, err)
+		require.NoErrorf(t, compound.Validate(), "size=%d", size)
+		for i := 1; i < size; i++ {
+			other, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+			require.NoError(t, err)
+			require.NoErrorf(t, other.Validate(), "size=%d i=%d", size, i)
+			require.NoError(t, compound.Merge(other))
+			require.NoErrorf(t, compound.Validate(), "size=%d i=%d", size, i)
+		}
+	}
+
+	t.Run("flagged txids computing different roots fail", func(t *testing.T) {
+		_, merkles := testBlock(t, 8)
+		bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 0)
+		require.NoError(t, err)
+		other, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 7)
+		require.NoError(t, err)
+		require.NoError(t, bump.Merge(other))
+
+		// level 2 offset 1 is only used by the path of txid 0.
+		l, ok := bump.leafAt(2, 1)
+		require.True(t, ok)
+		*l.Hash = txidExample
+
+		err = bump.Validate()
+		require.ErrorIs(t, err, ErrBUMPRootMismatch)
+		require.ErrorContains(t, err, "level 0 offset")
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/hex"
 	"log"
 	"sort"
+
+	"github.com/bsv-blockchain/go-bt/v2"
 )
 
 // implement `Interface` in sort package.
@@ -42,6 +44,28 @@ func SortByteArrays(src [][]byte) [][]byte {
 	return sorted
 }
 
+// ReadVarInt reads the VarInt starting at offset in b, returning its value and size.
+// ok is false if b is too short to contain it, so untrusted bytes can be read safely.
+func ReadVarInt(b []byte, offset int) (v uint64, size int, ok bool) {
+	if offset < 0 || offset >= len(b) {
+		return 0, 0, false
+	}
+	size = 1
+	switch b[offset] {
+	case 0xff:
+		size = 9
+	case 0xfe:
+		size = 5
+	case 0xfd:
+		size = 3
+	}
+	if len(b)-offset < size {
+		return 0, 0, false
+	}
+	vi, _ := bt.NewVarIntFromBytes(b[offset:])
+	return uint64(vi), size, true
+}
+
 // ReverseHexString reverses the hex string (little endian/big endian).
 // This is used when computing merkle trees in Bitcoin, for example.
 func ReverseHexString(hex string) string {
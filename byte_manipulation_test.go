@@ -6,6 +6,7 @@ import (
 
 	"github.com/bsv-blockchain/go-bt/v2"
 	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+	"github.com/stretchr/testify/require"
 
 	"github.com/bsv-blockchain/go-bc"
 )
@@ -61,3 +62,53 @@ func TestReverseHexString(t *testing.T) {
 		t.Errorf("Expected reversed string to be '%+v', got %+v", expectedLong, rhLong)
 	}
 }
+
+func TestReadVarInt(t *testing.T) {
+	tests := map[string]struct {
+		b       []byte
+		offset  int
+		expV    uint64
+		expSize int
+		expOK   bool
+	}{
+		"single byte": {
+			b:       []byte{0x07},
+			expV:    7,
+			expSize: 1,
+			expOK:   true,
+		},
+		"fd prefix": {
+			b:       []byte{0x00, 0xfd, 0x01, 0x02},
+			offset:  1,
+			expV:    0x0201,
+			expSize: 3,
+			expOK:   true,
+		},
+		"fe prefix truncated": {
+			b:     []byte{0xfe, 0x01, 0x02, 0x03},
+			expOK: false,
+		},
+		"ff prefix truncated": {
+			b:     []byte{0xff, 0x01},
+			expOK: false,
+		},
+		"offset at end": {
+			b:      []byte{0x01},
+			offset: 1,
+			expOK:  false,
+		},
+		"negative offset": {
+			b:      []byte{0x01},
+			offset: -1,
+			expOK:  false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, size, ok := bc.ReadVarInt(test.b, test.offset)
+			require.Equal(t, test.expOK, ok)
+			require.Equal(t, test.expV, v)
+			require.Equal(t, test.expSize, size)
+		})
+	}
+}
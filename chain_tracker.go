@@ -0,0 +1,84 @@
+package bc
+
+import (
+	"context"
+	"math"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/transaction/chaintracker"
+)
+
+var _ chaintracker.ChainTracker = (*ChainTracker)(nil)
+
+// ChainTracker adapts a HeightBlockHeaderChain to the go-sdk chaintracker.ChainTracker
+// interface, so the same header source can be used to verify go-sdk BEEF and
+// MerklePaths.
+type ChainTracker struct {
+	hbc HeightBlockHeaderChain
+}
+
+// NewChainTracker returns a go-sdk chaintracker.ChainTracker backed by hbc.
+func NewChainTracker(hbc HeightBlockHeaderChain) *ChainTracker {
+	return &ChainTracker{hbc: hbc}
+}
+
+// IsValidRootForHeight reports whether root is the merkle root of the block at height
+// on the longest chain.
+func (c *ChainTracker) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	if root == nil {
+		return false, nil
+	}
+	return c.hbc.IsValidRootForHeight(ctx, root.String(), uint64(height))
+}
+
+// CurrentHeight returns the height of the tip of the longest chain.
+func (c *ChainTracker) CurrentHeight(ctx context.Context) (uint32, error) {
+	_, height, err := c.hbc.ChainTip(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if height > math.MaxUint32 {
+		return 0, ErrHeightOutOfRange
+	}
+	return uint32(height), nil
+}
+
+// ChainTrackerHeaderChain adapts a go-sdk chaintracker.ChainTracker to a BlockHeaderChain
+// which can confirm merkle roots by height, so a ChainTracker can be used to verify BUMPs
+// with spv VerifyBUMP and BEEF with spv VerifyPayment.
+//
+// A ChainTracker can't look headers up by block hash or by height, so BlockHeader always
+// returns ErrHeaderNotFound, proofs targeting a block hash can't be verified with it and the
+// spv BUMPValidation of a BUMP doesn't include the block hash.
+type ChainTrackerHeaderChain struct {
+	ct chaintracker.ChainTracker
+}
+
+// NewChainTrackerHeaderChain returns a BlockHeaderChain backed by ct.
+func NewChainTrackerHeaderChain(ct chaintracker.ChainTracker) *ChainTrackerHeaderChain {
+	return &ChainTrackerHeaderChain{ct: ct}
+}
+
+// BlockHeader always returns ErrHeaderNotFound, as a ChainTracker only knows merkle roots.
+func (c *ChainTrackerHeaderChain) BlockHeader(_ context.Context, _ string) (*BlockHeader, error) {
+	return nil, ErrHeaderNotFound
+}
+
+// IsValidRootForHeight reports whether merkleRoot, as a hex string, is the merkle root of
+// the block at height according to the ChainTracker.
+func (c *ChainTrackerHeaderChain) IsValidRootForHeight(ctx context.Context, merkleRoot string, height uint64) (bool, error) {
+	if height > math.MaxUint32 {
+		return false, nil
+	}
+	root, err := chainhash.NewHashFromHex(merkleRoot)
+	if err != nil {
+		return false, err
+	}
+	return c.ct.IsValidRootForHeight(ctx, root, uint32(height))
+}
+
+// CurrentHeight returns the height of the tip of the chain according to the ChainTracker.
+func (c *ChainTrackerHeaderChain) CurrentHeight(ctx context.Context) (uint64, error) {
+	height, err := c.ct.CurrentHeight(ctx)
+	return uint64(height), err
+}
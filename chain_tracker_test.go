@@ -0,0 +1,144 @@
+package bc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/chaintracker"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+	"github.com/bsv-blockchain/go-bc/testing/data"
+)
+
+// fixtureHeaders are the block hashes of the headers in testing/data/bhc, in the order
+// fixtureHeaderChain gives them heights.
+var fixtureHeaders = []string{
+	"0994eeb6386321c276177d52be4879ed4f8fedaa942cca6ecf18d66cf08962ef",
+	"36be291597f4058ff4f9c6de9f89447dcdc6b8a5a53845b0e5bbd23d66488a53",
+	"37164112269dc4ee58a25df1a462ecd970ebad8cbbdebdb34d170ccb096ea62a",
+	"4100429a6a29fd8ddf480f124f02557df39d9d58a671c9ea0a8f1fcc8ace923f",
+	"4f35d06cd4d00dcba92ade34b4c507c2939d3d1393f490a370c5f4239050dbcb",
+	"6d731287ed58cfd267bce5ac7bd7bb4ab5a7589bf532db430bacfe0e79c083c5",
+	"6f25cdc8bb3305f5b5d7b83099065ff91e517218a6835e92db057500448a4709",
+	"6f2c5a14033b6082fb160cc2603d2047f30df4bcc07b506c5de97dd9b10d4477",
+	"730548cc946deba119fcee6ab2415bbb5fd8e0b41c9c0d5cae1ab069f905f56d",
+}
+
+// fixtureHeaderChain is a bc.HeightBlockHeaderChain over the fixture headers, which
+// places fixtureHeaders[i] at height i.
+type fixtureHeaderChain struct {
+	headers []*bc.BlockHeader
+}
+
+func newFixtureHeaderChain(t *testing.T) *fixtureHeaderChain {
+	t.Helper()
+	c := &fixtureHeaderChain{}
+	for _, hash := range fixtureHeaders {
+		b, err := data.BlockHeaderData.Load(hash)
+		require.NoError(t, err)
+		bh, err := bc.NewBlockHeaderFromStr(string(b[:160]))
+		require.NoError(t, err)
+		c.headers = append(c.headers, bh)
+	}
+	return c
+}
+
+func (c *fixtureHeaderChain) BlockHeader(_ context.Context, blockHash string) (*bc.BlockHeader, error) {
+	for i, hash := range fixtureHeaders {
+		if hash == blockHash {
+			return c.headers[i], nil
+		}
+	}
+	return nil, bc.ErrHeaderNotFound
+}
+
+func (c *fixtureHeaderChain) BlockHeaderByHeight(_ context.Context, height uint64) (*bc.BlockHeader, error) {
+	if height >= uint64(len(c.headers)) {
+		return nil, bc.ErrHeaderNotFound
+	}
+	return c.headers[height], nil
+}
+
+func (c *fixtureHeaderChain) ChainTip(_ context.Context) (*bc.BlockHeader, uint64, error) {
+	return c.headers[len(c.headers)-1], uint64(len(c.headers) - 1), nil
+}
+
+func (c *fixtureHeaderChain) IsValidRootForHeight(_ context.Context, merkleRoot string, height uint64) (bool, error) {
+	if height >= uint64(len(c.headers)) {
+		return false, nil
+	}
+	return c.headers[height].HashMerkleRootStr() == merkleRoot, nil
+}
+
+func TestChainTracker(t *testing.T) {
+	ctx := context.Background()
+	fixtures := newFixtureHeaderChain(t)
+	var ct chaintracker.ChainTracker = bc.NewChainTracker(fixtures)
+
+	height, err := ct.CurrentHeight(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint32(len(fixtureHeaders)-1), height)
+
+	for i, bh := range fixtures.headers {
+		root, err := chainhash.NewHashFromHex(bh.HashMerkleRootStr())
+		require.NoError(t, err)
+
+		valid, err := ct.IsValidRootForHeight(ctx, root, uint32(i))
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		valid, err = ct.IsValidRootForHeight(ctx, root, uint32(i+1))
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+
+	t.Run("go-sdk merkle path verifies", func(t *testing.T) {
+		// a block containing only a coinbase has the coinbase txid as its merkle root
+		root, err := chainhash.NewHashFromHex(fixtures.headers[3].HashMerkleRootStr())
+		require.NoError(t, err)
+		isTxid := true
+		mp := transaction.NewMerklePath(3, [][]*transaction.PathElement{{{Offset: 0, Hash: root, Txid: &isTxid}}})
+
+		valid, err := mp.Verify(ctx, root, ct)
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		mp.BlockHeight = 4
+		valid, err = mp.Verify(ctx, root, ct)
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+}
+
+func TestChainTrackerHeaderChain(t *testing.T) {
+	ctx := context.Background()
+	fixtures := newFixtureHeaderChain(t)
+	c := bc.NewChainTrackerHeaderChain(bc.NewChainTracker(fixtures))
+
+	for i, bh := range fixtures.headers {
+		valid, err := c.IsValidRootForHeight(ctx, bh.HashMerkleRootStr(), uint64(i))
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		valid, err = c.IsValidRootForHeight(ctx, bh.HashMerkleRootStr(), uint64(i+1))
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+
+	height, err := c.CurrentHeight(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(len(fixtureHeaders)-1), height)
+
+	_, err = c.BlockHeader(ctx, fixtureHeaders[0])
+	require.ErrorIs(t, err, bc.ErrHeaderNotFound)
+
+	valid, err := c.IsValidRootForHeight(ctx, fixtures.headers[0].HashMerkleRootStr(), 1<<32)
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	_, err = c.IsValidRootForHeight(ctx, "zz", 0)
+	require.Error(t, err)
+}
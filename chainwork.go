@@ -0,0 +1,75 @@
+package bc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+// ChainWork is the total work of a chain of headers, the sum of the Work of each of
+// them, which decides which of two chains is the longest. The zero value is no work.
+type ChainWork struct {
+	work *big.Int
+}
+
+// NewChainWork returns the ChainWork of headers.
+func NewChainWork(headers ...*BlockHeader) (ChainWork, error) {
+	var cw ChainWork
+	for _, bh := range headers {
+		var err error
+		if cw, err = cw.Add(bh); err != nil {
+			return ChainWork{}, err
+		}
+	}
+	return cw, nil
+}
+
+// NewChainWorkFromStr parses a ChainWork from hex, as reported in the chainwork field
+// of a node's getblockheader.
+func NewChainWorkFromStr(s string) (ChainWork, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return ChainWork{}, err
+	}
+	if len(b) > 32 {
+		return ChainWork{}, fmt.Errorf("%w: %d bytes", ErrInvalidChainWork, len(b))
+	}
+	return ChainWork{work: new(big.Int).SetBytes(b)}, nil
+}
+
+// Add returns the ChainWork of cw extended by bh.
+func (cw ChainWork) Add(bh *BlockHeader) (ChainWork, error) {
+	work, err := bh.Work()
+	if err != nil {
+		return ChainWork{}, err
+	}
+	return ChainWork{work: work.Add(work, cw.Int())}, nil
+}
+
+// Sum returns the ChainWork of cw and other together.
+func (cw ChainWork) Sum(other ChainWork) ChainWork {
+	return ChainWork{work: new(big.Int).Add(cw.Int(), other.Int())}
+}
+
+// Cmp compares cw and other and returns -1 if cw has less work, 0 if they have the same
+// and +1 if cw has more.
+func (cw ChainWork) Cmp(other ChainWork) int {
+	return cw.Int().Cmp(other.Int())
+}
+
+// Int returns the work as a new big.Int.
+func (cw ChainWork) Int() *big.Int {
+	if cw.work == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(cw.work)
+}
+
+// String returns the work as 64 hex characters, as reported by a node.
+func (cw ChainWork) String() string {
+	work := cw.Int()
+	if work.BitLen() > 256 {
+		return hex.EncodeToString(work.Bytes())
+	}
+	return hex.EncodeToString(work.FillBytes(make([]byte, 32)))
+}
@@ -0,0 +1,57 @@
+package bc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+func TestChainWork(t *testing.T) {
+	t.Parallel()
+
+	genesis, err := bc.NewBlockHeaderFromStr(mainnetGenesis)
+	require.NoError(t, err)
+	block1, err := bc.NewBlockHeaderFromStr(mainnetBlock1)
+	require.NoError(t, err)
+
+	// chainwork as reported by getblockheader for blocks 0 and 1.
+	cw, err := bc.NewChainWork(genesis)
+	require.NoError(t, err)
+	require.Equal(t, "0000000000000000000000000000000000000000000000000000000100010001", cw.String())
+	cw, err = cw.Add(block1)
+	require.NoError(t, err)
+	require.Equal(t, "0000000000000000000000000000000000000000000000000000000200020002", cw.String())
+
+	parsed, err := bc.NewChainWorkFromStr(cw.String())
+	require.NoError(t, err)
+	require.Equal(t, 0, cw.Cmp(parsed))
+
+	both, err := bc.NewChainWork(genesis, block1)
+	require.NoError(t, err)
+	require.Equal(t, 0, cw.Cmp(both))
+
+	var zero bc.ChainWork
+	require.Equal(t, 1, cw.Cmp(zero))
+	require.Equal(t, -1, zero.Cmp(cw))
+	require.Equal(t, 0, zero.Sum(cw).Cmp(cw))
+	require.Equal(t, "0000000000000000000000000000000000000000000000000000000400040004", cw.Sum(cw).String())
+
+	_, err = bc.NewChainWork(&bc.BlockHeader{Bits: []byte{0x20}})
+	require.ErrorIs(t, err, bc.ErrInvalidBits)
+	_, err = bc.NewChainWorkFromStr("zz")
+	require.Error(t, err)
+	_, err = bc.NewChainWorkFromStr("00" + cw.String())
+	require.ErrorIs(t, err, bc.ErrInvalidChainWork)
+}
+
+func TestChainWorkFixtureHeaders(t *testing.T) {
+	t.Parallel()
+
+	// every regtest header has a work of 2.
+	c := newFixtureHeaderChain(t)
+	cw, err := bc.NewChainWork(c.headers...)
+	require.NoError(t, err)
+	require.Equal(t, int64(2*len(fixtureHeaders)), cw.Int().Int64())
+}
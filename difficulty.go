@@ -3,39 +3,12 @@ package bc
 import (
 	"encoding/binary"
 	"encoding/hex"
-	"log"
+	"fmt"
 	"math"
 	"math/big"
 	"strconv"
 )
 
-var (
-	genesisDiff float64
-	regDiff     float64
-)
-
-//nolint:gochecknoinits // this needs to be refactored to avoid using init
-func init() {
-	bn, err := ExpandTargetFromAsInt("1d00ffff")
-	if err != nil {
-		log.Printf("Error: %+v", err)
-	}
-
-	bf := big.NewFloat(0)
-	bf.SetInt(bn)
-	genesisDiff, _ = bf.Float64()
-	genesisDiff = math.Pow(2, 256) / genesisDiff
-
-	bn, err = ExpandTargetFromAsInt("207fffff")
-	if err != nil {
-		log.Printf("Error: %+v", err)
-	}
-
-	bf.SetInt(bn)
-	regDiff, _ = bf.Float64()
-	regDiff = math.Pow(2, 256) / regDiff
-}
-
 // ExpandTargetFrom comment.
 func ExpandTargetFrom(bits string) (string, error) {
 	bn, err := ExpandTargetFromAsInt(bits)
@@ -56,6 +29,9 @@ func ExpandTargetFromAsInt(bits string) (*big.Int, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(binaryBits) != 4 {
+		return nil, ErrInvalidBits
+	}
 	compact := binary.BigEndian.Uint32(binaryBits)
 
 	// Extract the mantissa, sign bit, and exponent.
@@ -85,15 +61,71 @@ func ExpandTargetFromAsInt(bits string) (*big.Int, error) {
 	return bn, nil
 }
 
+// BigToCompact returns the compact encoding of n used in the nBits field of a block
+// header, the inverse of ExpandTargetFromAsInt. Only the 3 most significant bytes of n
+// are kept, and the encoding is canonical: the mantissa is shifted down a byte rather
+// than setting the sign bit, which is only set for negative numbers.
+func BigToCompact(n *big.Int) uint32 {
+	if n.Sign() == 0 {
+		return 0
+	}
+
+	abs := new(big.Int).Abs(n)
+	// The exponent is the number of bytes needed to represent n, and the
+	// mantissa its most significant 3 bytes.
+	exponent := uint((abs.BitLen() + 7) / 8) //nolint:gosec // G115: Safe conversion - BitLen is never negative
+	var mantissa uint32
+	if exponent <= 3 {
+		mantissa = uint32(abs.Uint64()) << (8 * (3 - exponent)) //nolint:gosec // G115: Safe conversion - abs fits in 3 bytes
+	} else {
+		mantissa = uint32(abs.Rsh(abs, 8*(exponent-3)).Uint64()) //nolint:gosec // G115: Safe conversion - abs fits in 3 bytes
+	}
+
+	// The top bit of the mantissa is the sign bit, so a mantissa using it
+	// is shifted down a byte and the exponent increased to compensate.
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		exponent++
+	}
+
+	compact := uint32(exponent<<24) | mantissa //nolint:gosec // G115: Safe conversion - targets are at most 256 bits
+	if n.Sign() < 0 {
+		compact |= 0x00800000
+	}
+	return compact
+}
+
+// TargetToBits returns the nBits of target in the byte order of BlockHeader.Bits,
+// so that hex encoded it can be expanded by ExpandTargetFromAsInt.
+func TargetToBits(target *big.Int) []byte {
+	bits := make([]byte, 4)
+	binary.BigEndian.PutUint32(bits, BigToCompact(target))
+	return bits
+}
+
+// DifficultyToTarget returns the target of difficulty, the genesis target divided by
+// the difficulty, the inverse of DifficultyFromBits.
+func DifficultyToTarget(difficulty float64) (*big.Int, error) {
+	if difficulty <= 0 || math.IsInf(difficulty, 0) || math.IsNaN(difficulty) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDifficulty, difficulty)
+	}
+	genesis, _ := ExpandTargetFromAsInt("1d00ffff")
+	target, _ := new(big.Float).Quo(new(big.Float).SetInt(genesis), big.NewFloat(difficulty)).Int(nil)
+	return target, nil
+}
+
 // DifficultyToHashrate takes a specific coin ticker, it's difficulty, and target
-// and computes the estimated hashrate on that specific coin (or chain).
+// and computes the estimated hashrate on that specific coin (or chain). Tickers
+// starting with R are treated as regtest, and all others as mainnet.
+//
+// Deprecated: use Params.DifficultyToHashrate.
 func DifficultyToHashrate(coin string, diff uint64, targetSeconds float64) float64 {
-	genesis := genesisDiff
-	if coin[0] == 'R' {
-		genesis = regDiff
+	params := &MainNetParams
+	if coin != "" && coin[0] == 'R' {
+		params = &RegTestParams
 	}
 
-	return float64(diff) * genesis / targetSeconds
+	return params.DifficultyToHashrate(diff, targetSeconds)
 }
 
 // DifficultyFromBits returns the mining difficulty from the nBits field in the block header.
@@ -0,0 +1,230 @@
+package bc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// cashWorkWindow is the number of blocks the CW-144 difficulty adjustment averages
+	// the work and time of.
+	cashWorkWindow = 144
+	// edaWindow is the number of headers the emergency difficulty adjustment needs to
+	// compare the median-time-past of the parent with that six blocks before it.
+	edaWindow = 6 + medianTimeSpan
+	// edaTimespan is how long six blocks can take, in seconds, before the emergency
+	// difficulty adjustment lowers the difficulty.
+	edaTimespan = 12 * 60 * 60
+)
+
+// retargetInterval is the number of blocks between adjustments of the original
+// difficulty adjustment.
+func (p *Params) retargetInterval() uint64 {
+	return uint64(p.TargetTimespan / p.TargetSpacing)
+}
+
+// DifficultyWindow returns the number of headers preceding the block at height which
+// ExpectedBits needs to be given.
+func (p *Params) DifficultyWindow(height uint64) int {
+	interval := p.retargetInterval()
+	switch {
+	case height == 0:
+		return 0
+	case p.NoRetargeting:
+		return 1
+	case p.IsDAAEnabled(height):
+		// the first block of the window is chosen from it and the two blocks before it.
+		return cashWorkWindow + 3
+	case height%interval == 0:
+		return int(interval) //nolint:gosec // G115: Safe conversion - the interval is 2016 blocks
+	}
+
+	if p.AllowMinDifficultyBlocks {
+		// the window reaches back to the last retarget.
+		return int(height % interval) //nolint:gosec // G115: Safe conversion - bounded by the interval
+	}
+	if p.IsUAHFEnabled(height) {
+		return edaWindow
+	}
+	return 1
+}
+
+// ExpectedBits returns the bits, in the byte order of BlockHeader.Bits, which consensus
+// requires of the block at height with the given time. window holds the headers
+// preceding it, oldest first and ending with its parent, and must hold at least
+// DifficultyWindow(height) of them, else ErrDifficultyWindowTooShort is returned.
+//
+// The difficulty adjustment is chosen by height: the original retarget every 2016
+// blocks, then from the August 2017 fork also the emergency difficulty adjustment, and
+// from the November 2017 fork the CW-144 adjustment.
+func (p *Params) ExpectedBits(window []*BlockHeader, height uint64, time uint32) ([]byte, error) {
+	if need := p.DifficultyWindow(height); len(window) < need {
+		return nil, fmt.Errorf("%w: %d headers given for height %d, %d needed", ErrDifficultyWindowTooShort, len(window), height, need)
+	}
+	if height == 0 {
+		return p.PowLimitBits(), nil
+	}
+	w := difficultyWindow{headers: window, height: height}
+	parent := w.ancestor(height - 1)
+	if p.NoRetargeting {
+		return parent.Bits, nil
+	}
+
+	switch {
+	case p.IsDAAEnabled(height):
+		return p.cashWorkBits(w, time)
+	case height%p.retargetInterval() == 0:
+		return p.retargetBits(w)
+	default:
+		return p.edaBits(w, time)
+	}
+}
+
+// difficultyWindow is the headers preceding the block at height.
+type difficultyWindow struct {
+	headers []*BlockHeader
+	height  uint64
+}
+
+// ancestor returns the header at height, which must be in the window.
+func (w difficultyWindow) ancestor(height uint64) *BlockHeader {
+	return w.headers[uint64(len(w.headers))-(w.height-height)]
+}
+
+// medianTimePast returns the median-time-past of the header at height.
+func (w difficultyWindow) medianTimePast(height uint64) uint32 {
+	end := uint64(len(w.headers)) - (w.height - height) + 1
+	start := uint64(0)
+	if end > medianTimeSpan {
+		start = end - medianTimeSpan
+	}
+	return MedianTimePast(w.headers[start:end])
+}
+
+// retargetBits is the original difficulty adjustment, scaling the target of the parent
+// by how far the last interval's time was from TargetTimespan, by at most a factor of 4.
+func (p *Params) retargetBits(w difficultyWindow) ([]byte, error) {
+	parent := w.ancestor(w.height - 1)
+	first := w.ancestor(w.height - p.retargetInterval())
+
+	timespan := int64(p.TargetTimespan.Seconds())
+	actual := int64(parent.Time) - int64(first.Time)
+	if actual < timespan/4 {
+		actual = timespan / 4
+	} else if actual > timespan*4 {
+		actual = timespan * 4
+	}
+
+	target, err := parent.Target()
+	if err != nil {
+		return nil, err
+	}
+	target.Mul(target, big.NewInt(actual))
+	return p.targetBits(target.Div(target, big.NewInt(timespan))), nil
+}
+
+// targetBits returns the bits of target, limited to PowLimit.
+func (p *Params) targetBits(target *big.Int) []byte {
+	if target.Cmp(p.PowLimit) > 0 {
+		return p.PowLimitBits()
+	}
+	return TargetToBits(target)
+}
+
+// isMinDifficulty reports whether the testnet rule allowing a block more than twice
+// TargetSpacing after its parent to have the PowLimit target applies.
+func (p *Params) isMinDifficulty(parent *BlockHeader, time uint32) bool {
+	return p.AllowMinDifficultyBlocks && int64(time) > int64(parent.Time)+2*int64(p.TargetSpacing.Seconds())
+}
+
+// edaBits is the bits between retargets. Where minimum difficulty blocks are allowed
+// they are those of the last block of the interval which isn't one, otherwise those of
+// the parent. After the August 2017 fork the emergency difficulty adjustment lowers the
+// difficulty by 20% if the last six blocks took over 12 hours.
+func (p *Params) edaBits(w difficultyWindow, time uint32) ([]byte, error) {
+	parent := w.ancestor(w.height - 1)
+	powLimitBits := p.PowLimitBits()
+	if p.AllowMinDifficultyBlocks {
+		if p.isMinDifficulty(parent, time) {
+			return powLimitBits, nil
+		}
+		bh, interval := parent, p.retargetInterval()
+		for h := w.height - 1; h%interval != 0 && bytes.Equal(bh.Bits, powLimitBits); {
+			h--
+			bh = w.ancestor(h)
+		}
+		return bh.Bits, nil
+	}
+
+	// the difficulty can't be lowered below the minimum.
+	if !p.IsUAHFEnabled(w.height) || bytes.Equal(parent.Bits, powLimitBits) {
+		return parent.Bits, nil
+	}
+	if int64(w.medianTimePast(w.height-1))-int64(w.medianTimePast(w.height-7)) < edaTimespan {
+		return parent.Bits, nil
+	}
+	target, err := parent.Target()
+	if err != nil {
+		return nil, err
+	}
+	return p.targetBits(target.Add(target, new(big.Int).Rsh(target, 2))), nil
+}
+
+// cashWorkBits is the CW-144 difficulty adjustment, the target which would have found
+// the work done over the last 144 blocks in TargetSpacing per block.
+func (p *Params) cashWorkBits(w difficultyWindow, time uint32) ([]byte, error) {
+	parent := w.ancestor(w.height - 1)
+	if p.isMinDifficulty(parent, time) {
+		return p.PowLimitBits(), nil
+	}
+
+	last := w.suitableBlock(w.height - 1)
+	first := w.suitableBlock(w.height - 1 - cashWorkWindow)
+
+	// the work done by the blocks after first up to and including last.
+	work := new(big.Int)
+	for h := first + 1; h <= last; h++ {
+		bw, err := w.ancestor(h).Work()
+		if err != nil {
+			return nil, err
+		}
+		work.Add(work, bw)
+	}
+
+	spacing := int64(p.TargetSpacing.Seconds())
+	actual := int64(w.ancestor(last).Time) - int64(w.ancestor(first).Time)
+	if actual > 288*spacing {
+		actual = 288 * spacing
+	} else if actual < 72*spacing {
+		actual = 72 * spacing
+	}
+	work.Mul(work, big.NewInt(spacing))
+	work.Div(work, big.NewInt(actual))
+	if work.Sign() == 0 {
+		return p.PowLimitBits(), nil
+	}
+
+	// the target with this work, (2^256 - work) / work.
+	target := new(big.Int).Lsh(big.NewInt(1), 256)
+	target.Sub(target, work)
+	return p.targetBits(target.Div(target, work)), nil
+}
+
+// suitableBlock returns the height of the block with the median time of the block at
+// height and the two before it, which protects the CW-144 adjustment from skewed
+// timestamps.
+func (w difficultyWindow) suitableBlock(height uint64) uint64 {
+	// sorted in the same order as the node, which matters when times are equal.
+	blocks := [3]uint64{height - 2, height - 1, height}
+	if w.ancestor(blocks[0]).Time > w.ancestor(blocks[2]).Time {
+		blocks[0], blocks[2] = blocks[2], blocks[0]
+	}
+	if w.ancestor(blocks[0]).Time > w.ancestor(blocks[1]).Time {
+		blocks[0], blocks[1] = blocks[1], blocks[0]
+	}
+	if w.ancestor(blocks[1]).Time > w.ancestor(blocks[2]).Time {
+		blocks[1], blocks[2] = blocks[2], blocks[1]
+	}
+	return blocks[1]
+}
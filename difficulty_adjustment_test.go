@@ -0,0 +1,190 @@
+package bc_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// bitsBytes returns compact bits in the byte order of BlockHeader.Bits.
+func bitsBytes(compact uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, compact)
+	return b
+}
+
+// headerWindow returns n headers spaced by spacing seconds, ending at time end, all
+// with the given bits.
+func headerWindow(n int, end, spacing uint32, compact uint32) []*bc.BlockHeader {
+	headers := make([]*bc.BlockHeader, 0, n)
+	for i := 0; i < n; i++ {
+		headers = append(headers, &bc.BlockHeader{
+			Time: end - uint32(n-1-i)*spacing,
+			Bits: bitsBytes(compact),
+		})
+	}
+	return headers
+}
+
+func TestExpectedBitsRetarget(t *testing.T) {
+	t.Parallel()
+
+	// the retarget cases of the node's pow tests.
+	tests := map[string]struct {
+		firstTime uint32
+		lastTime  uint32
+		height    uint64
+		bits      uint32
+		expected  uint32
+	}{
+		"retarget": {
+			firstTime: 1261130161, lastTime: 1262152739, height: 32256,
+			bits: 0x1d00ffff, expected: 0x1d00d86a,
+		},
+		"pow limit": {
+			firstTime: 1231006505, lastTime: 1233061996, height: 2016,
+			bits: 0x1d00ffff, expected: 0x1d00ffff,
+		},
+		"lower limit actual": {
+			firstTime: 1279008237, lastTime: 1279297671, height: 68544,
+			bits: 0x1c05a3f4, expected: 0x1c0168fd,
+		},
+		"upper limit actual": {
+			firstTime: 1263163443, lastTime: 1269211443, height: 46368,
+			bits: 0x1c387f6f, expected: 0x1d00e1fd,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			window := headerWindow(2016, test.lastTime, 600, test.bits)
+			window[0].Time = test.firstTime
+
+			bits, err := bc.MainNetParams.ExpectedBits(window, test.height, test.lastTime+600)
+			require.NoError(t, err)
+			require.Equal(t, bitsBytes(test.expected), bits)
+		})
+	}
+
+	// between retargets the bits of the parent are kept.
+	window := headerWindow(1, 1262152739, 600, 0x1c05a3f4)
+	bits, err := bc.MainNetParams.ExpectedBits(window, 32257, 1262153339)
+	require.NoError(t, err)
+	require.Equal(t, bitsBytes(0x1c05a3f4), bits)
+
+	_, err = bc.MainNetParams.ExpectedBits(window[:0], 32257, 1262153339)
+	require.ErrorIs(t, err, bc.ErrDifficultyWindowTooShort)
+	_, err = bc.MainNetParams.ExpectedBits(window, 32256, 1262153339)
+	require.ErrorIs(t, err, bc.ErrDifficultyWindowTooShort)
+}
+
+func TestExpectedBitsEDA(t *testing.T) {
+	t.Parallel()
+
+	const height = 480000
+	p := &bc.MainNetParams
+	require.Equal(t, 17, p.DifficultyWindow(height))
+
+	// blocks every 10 minutes keep the difficulty.
+	window := headerWindow(17, 1500000000, 600, 0x18014735)
+	bits, err := p.ExpectedBits(window, height, 1500000600)
+	require.NoError(t, err)
+	require.Equal(t, bitsBytes(0x18014735), bits)
+
+	// blocks every 3 hours lower it by 20%, increasing the target by a quarter.
+	window = headerWindow(17, 1500000000, 3*60*60, 0x18014735)
+	bits, err = p.ExpectedBits(window, height, 1500000600)
+	require.NoError(t, err)
+	require.Equal(t, bitsBytes(0x18019902), bits)
+
+	// the emergency adjustment only applies after the August 2017 fork.
+	window = headerWindow(17, 1500000000, 3*60*60, 0x18014735)
+	bits, err = p.ExpectedBits(window, p.UAHFHeight, 1500000600)
+	require.NoError(t, err)
+	require.Equal(t, bitsBytes(0x18014735), bits)
+}
+
+func TestExpectedBitsMinDifficulty(t *testing.T) {
+	t.Parallel()
+
+	p := &bc.TestNetParams
+	const height = 2016*10 + 5
+	window := headerWindow(5, 1500000000, 600, 0x1c05a3f4)
+	window[3].Bits = p.PowLimitBits()
+	window[4].Bits = p.PowLimitBits()
+
+	// a block over 20 minutes after its parent may have the minimum difficulty.
+	bits, err := p.ExpectedBits(window, height, 1500001201)
+	require.NoError(t, err)
+	require.Equal(t, p.PowLimitBits(), bits)
+
+	// otherwise it has the bits of the last block which didn't.
+	bits, err = p.ExpectedBits(window, height, 1500001200)
+	require.NoError(t, err)
+	require.Equal(t, bitsBytes(0x1c05a3f4), bits)
+}
+
+func TestExpectedBitsCashWork(t *testing.T) {
+	t.Parallel()
+
+	p := &bc.MainNetParams
+	const height = 600000
+	require.Equal(t, 147, p.DifficultyWindow(height))
+
+	bitsTarget := func(bits []byte) *big.Int {
+		target, err := bc.ExpandTargetFromAsInt(hex.EncodeToString(bits))
+		require.NoError(t, err)
+		return target
+	}
+	ratio := func(a, b *big.Int) float64 {
+		r, _ := new(big.Float).Quo(new(big.Float).SetInt(a), new(big.Float).SetInt(b)).Float64()
+		return r
+	}
+	target := bitsTarget(bitsBytes(0x18014735))
+
+	tests := map[string]struct {
+		spacing uint32
+		ratio   float64
+	}{
+		"on time":        {spacing: 600, ratio: 1},
+		"twice as fast":  {spacing: 300, ratio: 0.5},
+		"twice as slow":  {spacing: 1200, ratio: 2},
+		"clamped fast":   {spacing: 10, ratio: 0.5},
+		"clamped slow":   {spacing: 6000, ratio: 2},
+		"slightly early": {spacing: 590, ratio: 590.0 / 600},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			window := headerWindow(147, 1600000000, test.spacing, 0x18014735)
+			bits, err := p.ExpectedBits(window, height, 1600000000+test.spacing)
+			require.NoError(t, err)
+			require.InDelta(t, test.ratio, ratio(bitsTarget(bits), target), 1e-4)
+		})
+	}
+
+	// the middle time of each end of the window is used, so one skewed time is ignored.
+	window := headerWindow(147, 1600000000, 600, 0x18014735)
+	window[146].Time += 100000
+	window[2].Time -= 100000
+	bits, err := p.ExpectedBits(window, height, 1600000600)
+	require.NoError(t, err)
+	require.InDelta(t, 1, ratio(bitsTarget(bits), target), 1e-4)
+}
+
+func TestExpectedBitsNoRetargeting(t *testing.T) {
+	t.Parallel()
+
+	p := &bc.RegTestParams
+	bits, err := p.ExpectedBits(nil, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, p.PowLimitBits(), bits)
+
+	window := headerWindow(1, 1600000000, 600, 0x1c05a3f4)
+	bits, err = p.ExpectedBits(window, 2016, 1600000000)
+	require.NoError(t, err)
+	require.Equal(t, bitsBytes(0x1c05a3f4), bits)
+}
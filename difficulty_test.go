@@ -1,8 +1,14 @@
 package bc_test
 
 import (
+	"encoding/binary"
 	"encoding/hex"
+	"math/big"
+	"strings"
 	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/require"
 
 	"github.com/bsv-blockchain/go-bc"
 )
@@ -68,3 +74,94 @@ func testDifficulty(bits string, expected float64, t *testing.T) {
 		t.Errorf("Expected difficulty of '%s' to be '%v', got %v", bits, expected, d)
 	}
 }
+
+func TestBigToCompact(t *testing.T) {
+	tests := map[string]string{
+		"0":                                "00000000",
+		"12":                               "01120000",
+		"80":                               "02008000",
+		"1234":                             "02123400",
+		"123456":                           "03123456",
+		"12345678":                         "04123456",
+		"-1234":                            "02923400",
+		"7fffff" + strings.Repeat("0", 58): "207fffff",
+		"ffff" + strings.Repeat("0", 52):   "1d00ffff",
+		"2815ee" + strings.Repeat("0", 42): "182815ee",
+		"-ffff" + strings.Repeat("0", 52):  "1d80ffff",
+		"123456789abcdef" + strings.Repeat("0", 4): "0a012345",
+	}
+	for n, bits := range tests {
+		t.Run(n, func(t *testing.T) {
+			bn, ok := new(big.Int).SetString(n, 16)
+			require.True(t, ok)
+			require.Equal(t, bits, hex.EncodeToString(bc.TargetToBits(bn)))
+		})
+	}
+}
+
+// TestCompactRoundTrip checks that expanding the compact encoding of a number gives
+// the number truncated to its 3 most significant bytes, and that encoding is canonical.
+func TestCompactRoundTrip(t *testing.T) {
+	truncates := func(b [32]byte, negative bool) bool {
+		n := new(big.Int).SetBytes(b[:])
+		if negative {
+			n.Neg(n)
+		}
+		expanded, err := bc.ExpandTargetFromAsInt(hex.EncodeToString(bc.TargetToBits(n)))
+		if err != nil {
+			return false
+		}
+
+		// the bytes below the 3 most significant are dropped, where a leading zero byte
+		// is kept in place of the sign bit.
+		abs := new(big.Int).Abs(n)
+		if exponent := (abs.BitLen() + 8) / 8; exponent > 3 {
+			shift := uint(8 * (exponent - 3))
+			abs.Rsh(abs, shift).Lsh(abs, shift)
+		}
+		if negative {
+			abs.Neg(abs)
+		}
+		return expanded.Cmp(abs) == 0
+	}
+	require.NoError(t, quick.Check(truncates, nil))
+
+	canonical := func(compact uint32) bool {
+		bits := make([]byte, 4)
+		binary.BigEndian.PutUint32(bits, compact)
+		n, err := bc.ExpandTargetFromAsInt(hex.EncodeToString(bits))
+		if err != nil || n.BitLen() > 256 {
+			return true
+		}
+		// re-encoding keeps the value, and is then stable.
+		reencoded := bc.TargetToBits(n)
+		m, err := bc.ExpandTargetFromAsInt(hex.EncodeToString(reencoded))
+		if err != nil || m.Cmp(n) != 0 {
+			return false
+		}
+		return bc.BigToCompact(m) == binary.BigEndian.Uint32(reencoded)
+	}
+	require.NoError(t, quick.Check(canonical, nil))
+}
+
+func TestDifficultyToTarget(t *testing.T) {
+	for _, bits := range []string{"1d00ffff", "1745fb53", "182815ee", "207fffff"} {
+		b, err := hex.DecodeString(bits)
+		require.NoError(t, err)
+		d, err := bc.DifficultyFromBits(b)
+		require.NoError(t, err)
+
+		// the difficulty is a float64, so the target is only as precise as that.
+		target, err := bc.DifficultyToTarget(d)
+		require.NoError(t, err)
+		expected, err := bc.ExpandTargetFromAsInt(bits)
+		require.NoError(t, err)
+		ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(target), new(big.Float).SetInt(expected)).Float64()
+		require.InDelta(t, 1, ratio, 1e-9)
+	}
+
+	for _, d := range []float64{0, -1} {
+		_, err := bc.DifficultyToTarget(d)
+		require.ErrorIs(t, err, bc.ErrInvalidDifficulty)
+	}
+}
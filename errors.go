@@ -9,6 +9,18 @@ var (
 	// Block errors
 	ErrBlockEmpty               = errors.New("block cannot be empty")
 	ErrInvalidBlockHeaderLength = errors.New(errInvalidBlockHeaderLengthMsg)
+	ErrReadingBlock             = errors.New("failed to read block")
+	ErrInvalidBits              = errors.New("block header bits do not encode a valid target")
+	ErrInvalidDifficulty        = errors.New("difficulty must be a positive number")
+
+	// Block validation errors
+	ErrBlockNoTxs              = errors.New("block has no transactions")
+	ErrBlockNoCoinbase         = errors.New("first transaction of block is not a coinbase")
+	ErrBlockMultipleCoinbases  = errors.New("block has a coinbase after the first transaction")
+	ErrBlockDuplicateTx        = errors.New("block includes a transaction more than once")
+	ErrBlockMerkleMutated      = errors.New("block merkle tree hashes a node with a duplicate of itself")
+	ErrBlockMerkleRootMismatch = errors.New("block transactions do not have the merkle root of the header")
+	ErrBlockBIP34Height        = errors.New("block coinbase does not start with the block height")
 
 	// BUMP errors
 	ErrInsufficientBUMPData = errors.New("BUMP bytes do not contain enough data to be valid")
@@ -17,7 +29,55 @@ var (
 	ErrEmptyMerkleTree      = errors.New("merkle tree is empty")
 	ErrNoHashAtIndex        = errors.New("we do not have a hash for this index at height")
 
+	// BUMP validation errors
+	ErrBUMPLeafNoOffset       = errors.New("BUMP leaf has no offset")
+	ErrBUMPDuplicateOffset    = errors.New("BUMP level has more than one leaf at the offset")
+	ErrBUMPOffsetOutOfRange   = errors.New("BUMP leaf offset is beyond the width of its level")
+	ErrBUMPTxidNotAtLevelZero = errors.New("BUMP leaf flagged as a txid is not at level 0")
+	ErrBUMPInvalidDuplicate   = errors.New("BUMP duplicate leaf must be a right hand node without a hash or txid flag")
+	ErrBUMPLeafNoHash         = errors.New("BUMP leaf must have a 32 byte hex hash")
+
+	// Compound BUMP errors
+	ErrBUMPBlockHeightMismatch = errors.New("BUMPs are for different block heights")
+	ErrBUMPRootMismatch        = errors.New("BUMPs do not compute the same merkle root")
+	ErrBUMPNoTxids             = errors.New("no txids given to trim the BUMP to")
+
+	// Header chain errors
+	ErrHeaderChainNoRoot   = errors.New("header chain requires a root header")
+	ErrHeaderOrphan        = errors.New("header does not connect to a header in the chain")
+	ErrHeaderInvalidPoW    = errors.New("header does not satisfy the proof-of-work claimed in its bits")
+	ErrHeaderFileCorrupted = errors.New("header file does not start with the root header")
+	ErrHeightOutOfRange    = errors.New("block height does not fit in 32 bits")
+	ErrInvalidChainWork    = errors.New("chainwork is longer than 32 bytes")
+
+	// Network rule errors
+	ErrHeaderAbovePowLimit      = errors.New("header target is above the network proof-of-work limit")
+	ErrHeaderCheckpointMismatch = errors.New("header does not match the checkpoint at its height")
+	ErrDifficultyWindowTooShort = errors.New("not enough preceding headers to calculate the difficulty")
+	ErrHeaderBadBits            = errors.New("header bits are not those required by the difficulty adjustment")
+	ErrHeaderTimeTooOld         = errors.New("header time is not after the median time of the previous headers")
+	ErrHeaderTimeTooNew         = errors.New("header time is too far in the future")
+
+	// Parsing limit errors
+	ErrLimitExceeded = errors.New("resource limit exceeded parsing untrusted data")
+
 	// Merkle proof errors
-	ErrIndexOutOfRange    = errors.New("index out of range for proof")
-	ErrInvalidTransaction = errors.New("invalid transaction")
+	ErrIndexOutOfRange        = errors.New("index out of range for proof")
+	ErrInvalidMerkleProofNode = errors.New("merkle proof node is not a 32 byte hex hash or a valid duplicate")
+	ErrUnsupportedMerkleProof = errors.New("only single transaction merkle branch proofs can be converted")
+	ErrInvalidTransaction     = errors.New("invalid transaction")
+
+	// Merkle proof binary errors
+	ErrTruncatedMerkleProof       = errors.New("merkle proof bytes are truncated")
+	ErrInvalidMerkleFlags         = errors.New("invalid flags used in merkle proof")
+	ErrInvalidMerkleProofTxLength = errors.New("merkle proof tx must be longer than 32 bytes")
+	ErrInvalidMerkleProofNodeType = errors.New("invalid merkle proof node type")
+	ErrMerkleProofTrailingData    = errors.New("unexpected data after the last merkle proof node")
+
+	// Composite and tree merkle proof errors
+	ErrMerkleProofNoTxs          = errors.New("merkle proof has no transactions")
+	ErrMerkleProofMixedTxs       = errors.New("merkle proof mixes full transactions and transaction ids")
+	ErrMerkleProofRootMismatch   = errors.New("merkle proof transactions calculate different roots")
+	ErrMerkleProofMissingNodes   = errors.New("merkle proof does not have enough nodes")
+	ErrMerkleProofDuplicateIndex = errors.New("merkle proof proves the same index more than once")
 )
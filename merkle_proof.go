@@ -2,20 +2,42 @@ package bc
 
 import (
 	"encoding/hex"
+	"fmt"
 
 	"github.com/bsv-blockchain/go-bt/v2"
 )
 
+// Merkle proof flags, see Bytes.
+const (
+	merkleProofFlagTx         byte = 1 << 0
+	merkleProofFlagHeader     byte = 1 << 1
+	merkleProofFlagMerkleRoot byte = 1 << 2
+	merkleProofFlagTree       byte = 1 << 3
+	merkleProofFlagComposite  byte = 1 << 4
+)
+
 // A MerkleProof is a structure that proves the inclusion of a
 // Bitcoin transaction in a block.
+//
+// ProofType is "branch" (or empty), where Nodes hold the merkle branch of each
+// transaction, or "tree", where Nodes hold the partial merkle tree of the transactions
+// level by level, see CalculateRoot. A Composite proof proves every transaction in
+// Txs, rather than the one at Index and TxOrID.
 type MerkleProof struct {
-	Index      uint64   `json:"index"`
-	TxOrID     string   `json:"txOrId"`
-	Target     string   `json:"target"`
-	Nodes      []string `json:"nodes"`
-	TargetType string   `json:"targetType,omitempty"`
-	ProofType  string   `json:"proofType,omitempty"`
-	Composite  bool     `json:"composite,omitempty"`
+	Index      uint64          `json:"index"`
+	TxOrID     string          `json:"txOrId"`
+	Target     string          `json:"target"`
+	Nodes      []string        `json:"nodes"`
+	TargetType string          `json:"targetType,omitempty"`
+	ProofType  string          `json:"proofType,omitempty"`
+	Composite  bool            `json:"composite,omitempty"`
+	Txs        []MerkleProofTx `json:"txs,omitempty"`
+}
+
+// A MerkleProofTx is one of the transactions proven by a composite MerkleProof.
+type MerkleProofTx struct {
+	Index  uint64 `json:"index"`
+	TxOrID string `json:"txOrId"`
 }
 
 // Bytes convert the JSON Merkle Proof
@@ -23,21 +45,65 @@ type MerkleProof struct {
 //
 // Check the following encoding:
 //
-// flags: 			byte,
-// index: 			varint,
-// txLength: 		varint, //omitted if flag bit 0 == 0 as it's a fixed length transaction ID
-// txOrId: 			byte[32 or variable length],
-// target: 			byte[32 or 80], //determined by flag bits 1 and 2
-// nodeCount: 	varint,
-// nodes: 			node[]
+//	flags:     byte,
+//	txCount:   varint,  //only present if flag bit 4 == 1, for a composite proof
+//	index:     varint,  //index, txLength and txOrId are repeated txCount times for a composite proof
+//	txLength:  varint,  //omitted if flag bit 0 == 0 as it's a fixed length transaction ID
+//	txOrId:    byte[32 or txLength],
+//	target:    byte[32 or 80], //determined by flag bits 1 and 2
+//	nodeCount: varint,
+//	nodes:     node[nodeCount]
+//
+// The flag bits are:
+//
+//	bit 0: txOrId is a full transaction, for every transaction of a composite proof
+//	bit 1: target is a block header (80 bytes)
+//	bit 2: target is a merkle root (32 bytes), with neither bit 1 nor 2 set it is a block hash
+//	bit 3: the proof is a tree rather than a branch
+//	bit 4: the proof is composite
+//
+// and the others must not be set. Each node is a type byte of 0 followed by a 32 byte hash,
+// or a type byte of 1 alone for a duplicate ("*"). txOrId, target and the node hashes are
+// written in the reverse byte order of their hex strings.
+//
+// The TSC spec reserves flag bit 4 for composite proofs without defining them, so the
+// txCount field is an extension of it. A composite branch proof holds the branch of each
+// transaction in turn, all of the same length, while a composite tree proof holds a single
+// tree as described on CalculateRoot. For example, a composite tree proof of the txids at
+// indexes 1 and 4 targeting a merkle root is:
+//
+//	1c 02 01 <txid> 04 <txid> <merkle root> <nodeCount> <nodes>
 func (mp *MerkleProof) Bytes() ([]byte, error) {
-	index := bt.VarInt(mp.Index)
+	txs := mp.provenTxs()
+	if len(txs) == 0 {
+		return nil, ErrMerkleProofNoTxs
+	}
 
-	txOrID, err := hex.DecodeString(mp.TxOrID)
-	if err != nil {
-		return nil, err
+	var flags uint8
+	if len(txs[0].TxOrID) > 64 { // tx bytes instead of txid
+		// set a bit at index 0
+		flags |= merkleProofFlagTx
+	}
+
+	var txBytes []byte
+	if mp.Composite {
+		flags |= merkleProofFlagComposite
+		txBytes = append(txBytes, bt.VarInt(uint64(len(txs))).Bytes()...)
+	}
+	for _, tx := range txs {
+		txOrID, err := hex.DecodeString(tx.TxOrID)
+		if err != nil {
+			return nil, err
+		}
+		if (len(tx.TxOrID) > 64) != (flags&merkleProofFlagTx != 0) {
+			return nil, ErrMerkleProofMixedTxs
+		}
+		txBytes = append(txBytes, bt.VarInt(tx.Index).Bytes()...)
+		if flags&merkleProofFlagTx != 0 {
+			txBytes = append(txBytes, bt.VarInt(uint64(len(txOrID))).Bytes()...)
+		}
+		txBytes = append(txBytes, bt.ReverseBytes(txOrID)...)
 	}
-	txOrID = bt.ReverseBytes(txOrID)
 
 	target, err := hex.DecodeString(mp.Target)
 	if err != nil {
@@ -64,35 +130,191 @@ func (mp *MerkleProof) Bytes() ([]byte, error) {
 
 	}
 
-	var flags uint8
-
-	var txLength []byte
-	if len(mp.TxOrID) > 64 { // tx bytes instead of txid
-		// set a bit at index 0
-		flags |= 1 << 0
-
-		txLength = bt.VarInt(uint64(len(txOrID))).Bytes()
-	}
-
 	if mp.TargetType == "header" { //nolint:staticcheck // ignore switch
 		// set bit at index 1
-		flags |= 1 << 1
+		flags |= merkleProofFlagHeader
 	} else if mp.TargetType == "merkleRoot" {
 		// set a bit at index 2
-		flags |= 1 << 2
+		flags |= merkleProofFlagMerkleRoot
 	}
 
-	// ignore proofType and compositeType for this version
+	if mp.ProofType == "tree" {
+		// set a bit at index 3
+		flags |= merkleProofFlagTree
+	}
 
-	// Preallocate: 1 (flags) + 9 (index varint max) + 9 (txLength varint max) + txOrID + target + 1 (nodeCount) + nodes
-	bytes := make([]byte, 0, 20+len(txOrID)+len(target)+len(nodes))
+	// Preallocate: 1 (flags) + txs + target + 9 (nodeCount varint max) + nodes
+	bytes := make([]byte, 0, 10+len(txBytes)+len(target)+len(nodes))
 	bytes = append(bytes, flags)
-	bytes = append(bytes, index.Bytes()...)
-	bytes = append(bytes, txLength...)
-	bytes = append(bytes, txOrID...)
+	bytes = append(bytes, txBytes...)
 	bytes = append(bytes, target...)
-	bytes = append(bytes, byte(nodeCount)) //nolint:gosec // G115: Safe conversion - nodeCount is bounded by merkle proof structure
+	bytes = append(bytes, bt.VarInt(uint64(nodeCount)).Bytes()...)
 	bytes = append(bytes, nodes...)
 
 	return bytes, nil
 }
+
+// provenTxs returns the transactions the proof proves.
+func (mp *MerkleProof) provenTxs() []MerkleProofTx {
+	if mp.Composite {
+		return mp.Txs
+	}
+	return []MerkleProofTx{{Index: mp.Index, TxOrID: mp.TxOrID}}
+}
+
+// NewMerkleProofFromStr creates a MerkleProof from its binary encoding as a hex string.
+func NewMerkleProofFromStr(str string) (*MerkleProof, error) {
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+	return NewMerkleProofFromBytes(b)
+}
+
+// NewMerkleProofFromBytes decodes a MerkleProof from the binary encoding written by Bytes,
+// where the layout is described. When flag bit 4 is set the proof is composite and a varint
+// count of transactions precedes the index, txLength and txOrId of each of them.
+// Malformed input is rejected with ErrTruncatedMerkleProof, ErrInvalidMerkleFlags,
+// ErrInvalidMerkleProofTxLength, ErrInvalidMerkleProofNodeType, ErrIndexOutOfRange or
+// ErrMerkleProofTrailingData.
+func NewMerkleProofFromBytes(b []byte) (*MerkleProof, error) {
+	if len(b) == 0 {
+		return nil, ErrTruncatedMerkleProof
+	}
+	mp := &MerkleProof{}
+
+	flags := b[0]
+	offset := 1
+	known := merkleProofFlagTx | merkleProofFlagHeader | merkleProofFlagMerkleRoot | merkleProofFlagTree | merkleProofFlagComposite
+	if flags&^known != 0 {
+		return nil, fmt.Errorf("%w: %08b", ErrInvalidMerkleFlags, flags)
+	}
+	if flags&merkleProofFlagTree != 0 {
+		mp.ProofType = "tree"
+	}
+
+	txCount := uint64(1)
+	if flags&merkleProofFlagComposite != 0 {
+		mp.Composite = true
+		var (
+			size int
+			ok   bool
+		)
+		if txCount, size, ok = ReadVarInt(b, offset); !ok {
+			return nil, ErrTruncatedMerkleProof
+		}
+		offset += size
+		if txCount == 0 {
+			return nil, ErrMerkleProofNoTxs
+		}
+		// every tx is at least its index and 32 bytes.
+		if txCount > uint64(len(b)-offset)/33 {
+			return nil, ErrTruncatedMerkleProof
+		}
+		mp.Txs = make([]MerkleProofTx, 0, txCount)
+	}
+	var maxIndex uint64
+	for i := uint64(0); i < txCount; i++ {
+		tx, size, err := readMerkleProofTx(b, offset, flags&merkleProofFlagTx != 0)
+		if err != nil {
+			return nil, err
+		}
+		offset += size
+		if mp.Composite {
+			mp.Txs = append(mp.Txs, tx)
+		} else {
+			mp.Index, mp.TxOrID = tx.Index, tx.TxOrID
+		}
+		if tx.Index > maxIndex {
+			maxIndex = tx.Index
+		}
+	}
+
+	targetLength := 32
+	switch flags & (merkleProofFlagHeader | merkleProofFlagMerkleRoot) {
+	case 0:
+	case merkleProofFlagHeader:
+		mp.TargetType = "header"
+		targetLength = 80
+	case merkleProofFlagMerkleRoot:
+		mp.TargetType = "merkleRoot"
+	default:
+		return nil, fmt.Errorf("%w: %08b", ErrInvalidMerkleFlags, flags)
+	}
+	if len(b)-offset < targetLength {
+		return nil, ErrTruncatedMerkleProof
+	}
+	mp.Target = hex.EncodeToString(bt.ReverseBytes(b[offset : offset+targetLength]))
+	offset += targetLength
+
+	nodeCount, size, ok := ReadVarInt(b, offset)
+	if !ok {
+		return nil, ErrTruncatedMerkleProof
+	}
+	offset += size
+	// a branch proof has a node per level, so can't index beyond them. The number of levels
+	// of a tree proof depends on its transactions so is checked when it is calculated.
+	if !mp.Composite && mp.ProofType != "tree" && nodeCount < 64 && maxIndex >= 1<<nodeCount {
+		return nil, fmt.Errorf("%w: index %d with %d nodes", ErrIndexOutOfRange, maxIndex, nodeCount)
+	}
+	// every node is at least its type byte.
+	if nodeCount > uint64(len(b)-offset) {
+		return nil, ErrTruncatedMerkleProof
+	}
+
+	mp.Nodes = make([]string, 0, nodeCount)
+	for i := uint64(0); i < nodeCount; i++ {
+		if offset >= len(b) {
+			return nil, ErrTruncatedMerkleProof
+		}
+		nodeType := b[offset]
+		offset++
+		switch nodeType {
+		case 0:
+			if len(b)-offset < 32 {
+				return nil, ErrTruncatedMerkleProof
+			}
+			mp.Nodes = append(mp.Nodes, hex.EncodeToString(bt.ReverseBytes(b[offset:offset+32])))
+			offset += 32
+		case 1:
+			mp.Nodes = append(mp.Nodes, "*")
+		default:
+			return nil, fmt.Errorf("%w: %d at node %d", ErrInvalidMerkleProofNodeType, nodeType, i)
+		}
+	}
+	if offset != len(b) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMerkleProofTrailingData, len(b)-offset)
+	}
+
+	return mp, nil
+}
+
+// readMerkleProofTx reads the index, and txid or full tx when isTx is set, starting at
+// offset in b, returning the tx and the number of bytes read.
+func readMerkleProofTx(b []byte, offset int, isTx bool) (MerkleProofTx, int, error) {
+	start := offset
+	index, size, ok := ReadVarInt(b, offset)
+	if !ok {
+		return MerkleProofTx{}, 0, ErrTruncatedMerkleProof
+	}
+	offset += size
+
+	txLength := uint64(32)
+	if isTx {
+		// txOrId holds the full transaction
+		if txLength, size, ok = ReadVarInt(b, offset); !ok {
+			return MerkleProofTx{}, 0, ErrTruncatedMerkleProof
+		}
+		offset += size
+		if txLength <= 32 {
+			return MerkleProofTx{}, 0, ErrInvalidMerkleProofTxLength
+		}
+	}
+	if txLength > uint64(len(b)-offset) {
+		return MerkleProofTx{}, 0, ErrTruncatedMerkleProof
+	}
+	txOrID := hex.EncodeToString(bt.ReverseBytes(b[offset : offset+int(txLength)])) //nolint:gosec // G115: Safe conversion - txLength is bounded by len(b)
+	offset += int(txLength)                                                         //nolint:gosec // G115: Safe conversion - txLength is bounded by len(b)
+
+	return MerkleProofTx{Index: index, TxOrID: txOrID}, offset - start, nil
+}
@@ -0,0 +1,276 @@
+package bc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+)
+
+// duplicateNode is the MerkleProof node marking that the sibling is a copy of the
+// working hash, as happens at the end of a level with an odd number of nodes.
+const duplicateNode = "*"
+
+// merkleBranch is the path of a single transaction to its merkle root, which the
+// MerkleProof, MerklePath and BUMP formats can all be converted to and from. Nodes are
+// hex hashes, or duplicateNode where the sibling is a copy of the working hash.
+type merkleBranch struct {
+	index uint64
+	txid  string
+	nodes []string
+}
+
+// walk hashes the branch up to its merkle root, calling fn with the working hash and
+// the hash of its sibling, both in internal byte order, at every level.
+func (b *merkleBranch) walk(fn func(level int, working, sibling []byte)) ([]byte, error) {
+	working, err := hashFromHex(b.txid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: txid %s", ErrInvalidMerkleProofNode, b.txid)
+	}
+	for level, node := range b.nodes {
+		isRight := (b.index>>level)&1 == 1
+		var sibling []byte
+		if node == duplicateNode {
+			if isRight {
+				return nil, fmt.Errorf("%w: duplicate on the left at level %d", ErrInvalidMerkleProofNode, level)
+			}
+			sibling = working
+		} else if sibling, err = hashFromHex(node); err != nil {
+			return nil, fmt.Errorf("%w: level %d", ErrInvalidMerkleProofNode, level)
+		}
+		if fn != nil {
+			fn(level, working, sibling)
+		}
+		if isRight {
+			working = Sha256Sha256(append(append(make([]byte, 0, 64), sibling...), working...))
+		} else {
+			working = Sha256Sha256(append(append(make([]byte, 0, 64), working...), sibling...))
+		}
+	}
+	return working, nil
+}
+
+// merkleBranchFromMerkleProof returns the branch of a single transaction merkle proof.
+func merkleBranchFromMerkleProof(mp *MerkleProof) (*merkleBranch, error) {
+	if mp.Composite || (mp.ProofType != "" && mp.ProofType != "branch") {
+		return nil, ErrUnsupportedMerkleProof
+	}
+	txid := mp.TxOrID
+	if len(txid) > 64 {
+		// the proof holds the full transaction.
+		tx, err := hex.DecodeString(mp.TxOrID)
+		if err != nil {
+			return nil, err
+		}
+		txid = StringFromBytesReverse(Sha256Sha256(tx))
+	}
+	b := &merkleBranch{index: mp.Index, txid: txid, nodes: mp.Nodes}
+	if _, err := b.walk(nil); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// merkleBranchFromMerklePath returns the branch of txid in path, marking siblings which
+// are a copy of the working hash as duplicates.
+func merkleBranchFromMerklePath(path *MerklePath, txid string) (*merkleBranch, error) {
+	b := &merkleBranch{index: path.Index, txid: txid, nodes: append([]string(nil), path.Path...)}
+	_, err := b.walk(func(level int, working, sibling []byte) {
+		if (b.index>>level)&1 == 0 && bytes.Equal(working, sibling) {
+			b.nodes[level] = duplicateNode
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// merkleBranchFromBUMP returns the branch of txid in bump, computing nodes the BUMP
+// doesn't hold from the level below.
+func merkleBranchFromBUMP(bump *BUMP, txid string) (*merkleBranch, error) {
+	if len(bump.Path) == 0 {
+		return nil, ErrInsufficientBUMPData
+	}
+	l, ok := bump.txidLeaf(txid)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTxidNotInBUMP, txid)
+	}
+	b := &merkleBranch{index: *l.Offset, txid: txid}
+	if len(bump.Path) == 1 && len(bump.Path[0]) == 1 {
+		// the block only has one transaction, so there are no nodes.
+		return b, nil
+	}
+	b.nodes = make([]string, len(bump.Path))
+	for height := range bump.Path {
+		sibling, err := bump.siblingLeaf(height, (b.index>>height)^1)
+		if err != nil {
+			return nil, err
+		}
+		if sibling.Duplicate != nil {
+			b.nodes[height] = duplicateNode
+		} else {
+			b.nodes[height] = *sibling.Hash
+		}
+	}
+	return b, nil
+}
+
+func (b *merkleBranch) merkleProof(root []byte) *MerkleProof {
+	return &MerkleProof{
+		Index:      b.index,
+		TxOrID:     b.txid,
+		Target:     StringFromBytesReverse(root),
+		TargetType: "merkleRoot",
+		Nodes:      b.nodes,
+	}
+}
+
+func (b *merkleBranch) merklePath() (*MerklePath, error) {
+	path := &MerklePath{Index: b.index}
+	if len(b.nodes) == 0 {
+		return path, nil
+	}
+	path.Path = make([]string, len(b.nodes))
+	_, err := b.walk(func(level int, _, sibling []byte) {
+		path.Path[level] = StringFromBytesReverse(sibling)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+func (b *merkleBranch) bump(blockHeight uint64) *BUMP {
+	truePointer := true
+	txid := b.txid
+	offset := b.index
+	txidLeaf := leaf{Offset: &offset, Hash: &txid, Txid: &truePointer}
+	if len(b.nodes) == 0 {
+		return &BUMP{BlockHeight: blockHeight, Path: [][]leaf{{txidLeaf}}}
+	}
+
+	bump := &BUMP{BlockHeight: blockHeight, Path: make([][]leaf, len(b.nodes))}
+	for level, node := range b.nodes {
+		siblingOffset := (b.index >> level) ^ 1
+		sibling := leaf{Offset: &siblingOffset}
+		if node == duplicateNode {
+			sibling.Duplicate = &truePointer
+		} else {
+			hash := node
+			sibling.Hash = &hash
+		}
+		bump.Path[level] = []leaf{sibling}
+	}
+	if b.index&1 == 1 {
+		bump.Path[0] = append(bump.Path[0], txidLeaf)
+	} else {
+		bump.Path[0] = append([]leaf{txidLeaf}, bump.Path[0]...)
+	}
+	return bump
+}
+
+// NewMerklePathFromMerkleProof converts a single transaction, merkle branch, MerkleProof
+// to a BRC-58 MerklePath. Duplicate "*" nodes are replaced by the hash they stand for.
+func NewMerklePathFromMerkleProof(mp *MerkleProof) (*MerklePath, error) {
+	b, err := merkleBranchFromMerkleProof(mp)
+	if err != nil {
+		return nil, err
+	}
+	return b.merklePath()
+}
+
+// NewBUMPFromMerkleProof converts a single transaction, merkle branch, MerkleProof to a
+// BRC-74 BUMP. A MerkleProof doesn't record the height of its block, so it must be given.
+func NewBUMPFromMerkleProof(mp *MerkleProof, blockHeight uint64) (*BUMP, error) {
+	b, err := merkleBranchFromMerkleProof(mp)
+	if err != nil {
+		return nil, err
+	}
+	return b.bump(blockHeight), nil
+}
+
+// NewMerkleProofFromMerklePath converts the BRC-58 MerklePath of txid to a MerkleProof
+// targeting the merkle root it computes.
+func NewMerkleProofFromMerklePath(path *MerklePath, txid string) (*MerkleProof, error) {
+	b, err := merkleBranchFromMerklePath(path, txid)
+	if err != nil {
+		return nil, err
+	}
+	root, err := b.walk(nil)
+	if err != nil {
+		return nil, err
+	}
+	return b.merkleProof(root), nil
+}
+
+// NewBUMPFromMerklePath converts the BRC-58 MerklePath of txid to a BRC-74 BUMP. A
+// MerklePath doesn't record the height of its block, so it must be given.
+func NewBUMPFromMerklePath(path *MerklePath, txid string, blockHeight uint64) (*BUMP, error) {
+	b, err := merkleBranchFromMerklePath(path, txid)
+	if err != nil {
+		return nil, err
+	}
+	return b.bump(blockHeight), nil
+}
+
+// NewMerklePathFromBUMP returns the BRC-58 MerklePath of txid within bump.
+func NewMerklePathFromBUMP(bump *BUMP, txid string) (*MerklePath, error) {
+	b, err := merkleBranchFromBUMP(bump, txid)
+	if err != nil {
+		return nil, err
+	}
+	return b.merklePath()
+}
+
+// NewMerkleProofFromBUMP returns the MerkleProof of txid within bump. If hbc is nil the
+// proof targets the merkle root the BUMP computes, otherwise it targets the hash of the
+// block at the BUMP's height, which must have that merkle root.
+func NewMerkleProofFromBUMP(ctx context.Context, bump *BUMP, txid string, hbc HeightBlockHeaderChain) (*MerkleProof, error) {
+	b, err := merkleBranchFromBUMP(bump, txid)
+	if err != nil {
+		return nil, err
+	}
+	root, err := b.walk(nil)
+	if err != nil {
+		return nil, err
+	}
+	mp := b.merkleProof(root)
+	if mp.Target, mp.TargetType, err = merkleProofTarget(ctx, bump, mp.Target, hbc); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// merkleProofTarget returns the target, and its type, of a proof within bump with the
+// given merkle root: the root itself if hbc is nil, otherwise the hash of the block at
+// the BUMP's height, which must have that merkle root.
+func merkleProofTarget(ctx context.Context, bump *BUMP, root string, hbc HeightBlockHeaderChain) (string, string, error) {
+	if hbc == nil {
+		return root, "merkleRoot", nil
+	}
+
+	header, err := hbc.BlockHeaderByHeight(ctx, bump.BlockHeight)
+	if err != nil {
+		return "", "", err
+	}
+	if header.HashMerkleRootStr() != root {
+		return "", "", fmt.Errorf("%w: block at height %d has merkle root %s", ErrBUMPRootMismatch,
+			bump.BlockHeight, header.HashMerkleRootStr())
+	}
+	return header.HashStr(), "", nil
+}
+
+// hashFromHex decodes a 32 byte hex hash into internal byte order.
+func hashFromHex(s string) ([]byte, error) {
+	h, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(h) != 32 {
+		return nil, ErrInvalidMerkleProofNode
+	}
+	return bt.ReverseBytes(h), nil
+}
@@ -0,0 +1,180 @@
+package bc
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleProofConversionsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for size := 1; size <= 17; size++ {
+		txids, merkles := testBlock(t, size)
+		root := merkles[len(merkles)-1].String()
+		txidStrs := make([]string, 0, size)
+		for _, txid := range txids {
+			txidStrs = append(txidStrs, txid.String())
+		}
+		merkleTree, err := BuildMerkleTreeStore(txidStrs)
+		require.NoError(t, err)
+
+		for i := 0; i < size; i++ {
+			txid := txidStrs[i]
+			bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+			require.NoError(t, err)
+
+			path, err := NewMerklePathFromBUMP(bump, txid)
+			require.NoErrorf(t, err, "size=%d i=%d", size, i)
+			require.Equalf(t, GetTxMerklePath(i, merkleTree), path, "size=%d i=%d", size, i)
+			pathRoot, err := path.CalculateRoot(txid)
+			require.NoError(t, err)
+			require.Equal(t, root, pathRoot)
+
+			proof, err := NewMerkleProofFromBUMP(ctx, bump, txid, nil)
+			require.NoErrorf(t, err, "size=%d i=%d", size, i)
+			require.Equal(t, root, proof.Target)
+			require.Equal(t, "merkleRoot", proof.TargetType)
+			require.Len(t, proof.Nodes, len(path.Path))
+
+			fromProof, err := NewBUMPFromMerkleProof(proof, fakeMadeUpNum)
+			require.NoError(t, err)
+			require.Equalf(t, bump, fromProof, "size=%d i=%d", size, i)
+
+			fromPath, err := NewBUMPFromMerklePath(path, txid, fakeMadeUpNum)
+			require.NoError(t, err)
+			require.Equalf(t, bump, fromPath, "size=%d i=%d", size, i)
+
+			pathFromProof, err := NewMerklePathFromMerkleProof(proof)
+			require.NoError(t, err)
+			require.Equal(t, path, pathFromProof)
+
+			proofFromPath, err := NewMerkleProofFromMerklePath(path, txid)
+			require.NoError(t, err)
+			require.Equal(t, proof, proofFromPath)
+		}
+	}
+}
+
+func TestMerkleProofConversionsDuplicateNode(t *testing.T) {
+	// the last tx of a 3 tx block is paired with itself.
+	txids, merkles := testBlock(t, 3)
+	txid := txids[2].String()
+	bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 2)
+	require.NoError(t, err)
+
+	proof, err := NewMerkleProofFromBUMP(context.Background(), bump, txid, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"*", merkles[4].String()}, proof.Nodes)
+
+	path, err := NewMerklePathFromMerkleProof(proof)
+	require.NoError(t, err)
+	require.Equal(t, []string{txid, merkles[4].String()}, path.Path)
+}
+
+func TestMerkleProofConversionsFullTx(t *testing.T) {
+	tx, err := hex.DecodeString("0100000001cd4e4cac3c7b56920d1e7655e7e260d31f29d9a388d04910f1bbd72304a79029010000006b483045022100e75279a205a547c445719420aa3138bf14743e3f42618e5f86a19bde14bb95f7022064777d34776b05d816daf1699493fcdf2ef5a5ab1ad710d9c97bfb5b8f7cef3641210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013e660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac00000000")
+	require.NoError(t, err)
+	txid := StringFromBytesReverse(Sha256Sha256(tx))
+	require.Equal(t, txidExample, txid)
+
+	proof := &MerkleProof{
+		Index:  21,
+		TxOrID: hex.EncodeToString(tx),
+	}
+	bump, err := NewBUMPFromStr(hexExample)
+	require.NoError(t, err)
+	fromBUMP, err := NewMerkleProofFromBUMP(context.Background(), bump, txid, nil)
+	require.NoError(t, err)
+	proof.Nodes = fromBUMP.Nodes
+
+	converted, err := NewBUMPFromMerkleProof(proof, bump.BlockHeight)
+	require.NoError(t, err)
+	require.Equal(t, bump, converted)
+}
+
+func TestNewMerkleProofFromBUMPWithHeaderChain(t *testing.T) {
+	ctx := context.Background()
+	_, merkles := testBlock(t, 5)
+	txid := merkles[1].String()
+	bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, 1)
+	require.NoError(t, err)
+
+	root, err := hex.DecodeString(merkles[len(merkles)-1].String())
+	require.NoError(t, err)
+	header := &BlockHeader{
+		Version:        1,
+		HashPrevBlock:  make([]byte, 32),
+		HashMerkleRoot: root,
+		Bits:           []byte{0x20, 0x7f, 0xff, 0xff},
+	}
+	chain, err := NewMemoryBlockHeaderChain(header, fakeMadeUpNum)
+	require.NoError(t, err)
+
+	proof, err := NewMerkleProofFromBUMP(ctx, bump, txid, chain)
+	require.NoError(t, err)
+	require.Equal(t, header.HashStr(), proof.Target)
+	require.Empty(t, proof.TargetType)
+
+	bump.BlockHeight++
+	_, err = NewMerkleProofFromBUMP(ctx, bump, txid, chain)
+	require.ErrorIs(t, err, ErrHeaderNotFound)
+
+	other, err := NewMemoryBlockHeaderChain(&BlockHeader{
+		Version:        1,
+		HashPrevBlock:  make([]byte, 32),
+		HashMerkleRoot: make([]byte, 32),
+		Bits:           []byte{0x20, 0x7f, 0xff, 0xff},
+	}, bump.BlockHeight)
+	require.NoError(t, err)
+	_, err = NewMerkleProofFromBUMP(ctx, bump, txid, other)
+	require.ErrorIs(t, err, ErrBUMPRootMismatch)
+}
+
+func TestMerkleProofConversionsErrors(t *testing.T) {
+	txid := chainhash.Hash{1}.String()
+	node := chainhash.Hash{2}.String()
+
+	tests := map[string]struct {
+		proof  *MerkleProof
+		expErr error
+	}{
+		"duplicate on the left fails": {
+			proof:  &MerkleProof{Index: 1, TxOrID: txid, Nodes: []string{"*"}},
+			expErr: ErrInvalidMerkleProofNode,
+		},
+		"short node fails": {
+			proof:  &MerkleProof{Index: 0, TxOrID: txid, Nodes: []string{node, "abcd"}},
+			expErr: ErrInvalidMerkleProofNode,
+		},
+		"invalid txid fails": {
+			proof:  &MerkleProof{Index: 0, TxOrID: "zz", Nodes: []string{node}},
+			expErr: ErrInvalidMerkleProofNode,
+		},
+		"composite proof fails": {
+			proof:  &MerkleProof{Index: 0, TxOrID: txid, Nodes: []string{node}, Composite: true},
+			expErr: ErrUnsupportedMerkleProof,
+		},
+		"tree proof fails": {
+			proof:  &MerkleProof{Index: 0, TxOrID: txid, Nodes: []string{node}, ProofType: "tree"},
+			expErr: ErrUnsupportedMerkleProof,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewBUMPFromMerkleProof(test.proof, fakeMadeUpNum)
+			require.ErrorIs(t, err, test.expErr)
+			_, err = NewMerklePathFromMerkleProof(test.proof)
+			require.ErrorIs(t, err, test.expErr)
+		})
+	}
+
+	bump, err := NewBUMPFromStr(hexExample)
+	require.NoError(t, err)
+	_, err = NewMerklePathFromBUMP(bump, txid)
+	require.ErrorIs(t, err, ErrTxidNotInBUMP)
+	_, err = NewMerkleProofFromBUMP(context.Background(), bump, txid, nil)
+	require.ErrorIs(t, err, ErrTxidNotInBUMP)
+}
@@ -0,0 +1,49 @@
+package bc
+
+import "testing"
+
+// FuzzNewMerkleProofFromBytes ensures NewMerkleProofFromBytes never panics on malformed input
+// and that every proof it decodes encodes back to the same bytes.
+func FuzzNewMerkleProofFromBytes(f *testing.F) {
+	for _, mp := range []*MerkleProof{{
+		Index:  12,
+		TxOrID: "ffeff11c25cde7c06d407490d81ef4d0db64aad6ab3d14393530701561a465ef",
+		Target: "75edb0a69eb195cdd81e310553aa4d25e18450e08f168532a2c2e9cf447bf169",
+		Nodes: []string{
+			"b9ef07a62553ef8b0898a79c291b92c60f7932260888bde0dab2dd2610d8668e",
+			"*",
+		},
+	}, {
+		ProofType: "tree",
+		Composite: true,
+		Txs: []MerkleProofTx{
+			{Index: 0, TxOrID: "ffeff11c25cde7c06d407490d81ef4d0db64aad6ab3d14393530701561a465ef"},
+			{Index: 3, TxOrID: "b9ef07a62553ef8b0898a79c291b92c60f7932260888bde0dab2dd2610d8668e"},
+		},
+		Target:     "75edb0a69eb195cdd81e310553aa4d25e18450e08f168532a2c2e9cf447bf169",
+		TargetType: "merkleRoot",
+		Nodes:      []string{"*", "*"},
+	}} {
+		b, err := mp.Bytes()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Add([]byte{})
+	f.Add([]byte{0x01, 0x00, 0xff})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		mp, err := NewMerkleProofFromBytes(b)
+		if err != nil {
+			return
+		}
+		round, err := mp.Bytes()
+		if err != nil {
+			t.Fatalf("failed to encode decoded proof: %v", err)
+		}
+		if string(round) != string(b) {
+			t.Fatalf("round trip failed: %x != %x", round, b)
+		}
+	})
+}
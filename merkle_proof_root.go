@@ -0,0 +1,249 @@
+package bc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// TxIDs returns the ids of the transactions the proof proves, in the order they are
+// listed, hashing any full transactions.
+func (mp *MerkleProof) TxIDs() ([]string, error) {
+	txs := mp.provenTxs()
+	if len(txs) == 0 {
+		return nil, ErrMerkleProofNoTxs
+	}
+	txids := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		txid := tx.TxOrID
+		if len(txid) > 64 {
+			// the proof holds the full transaction.
+			b, err := hex.DecodeString(tx.TxOrID)
+			if err != nil {
+				return nil, err
+			}
+			txid = StringFromBytesReverse(Sha256Sha256(b))
+		}
+		txids = append(txids, txid)
+	}
+	return txids, nil
+}
+
+// CalculateRoot returns the merkle root, as a hex string, computed from the transactions
+// and nodes of the proof, and whether any of the transactions is the last in its block.
+//
+// A branch proof holds a node per level for each transaction, so a composite branch
+// proof has the branches of its transactions one after the other and each of them must
+// compute the same root.
+//
+// A tree proof holds, from the bottom level up, the sibling of every known node whose
+// sibling isn't known itself, in order of offset. A known node is one of the proven
+// transactions or the parent of a known node. It ends once the known nodes have been
+// hashed up to a single node at offset 0 and there are no nodes left.
+func (mp *MerkleProof) CalculateRoot() (string, bool, error) {
+	txids, err := mp.TxIDs()
+	if err != nil {
+		return "", false, err
+	}
+	txs := mp.provenTxs()
+
+	var (
+		root   []byte
+		isLast bool
+	)
+	if mp.ProofType == "tree" {
+		root, isLast, err = mp.treeRoot(txs, txids)
+	} else if mp.ProofType == "" || mp.ProofType == "branch" {
+		root, isLast, err = mp.branchRoot(txs, txids)
+	} else {
+		return "", false, fmt.Errorf("%w: proof type %s", ErrUnsupportedMerkleProof, mp.ProofType)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return StringFromBytesReverse(root), isLast, nil
+}
+
+// branchRoot computes the root of the branch of each transaction, which must agree.
+func (mp *MerkleProof) branchRoot(txs []MerkleProofTx, txids []string) ([]byte, bool, error) {
+	if len(mp.Nodes)%len(txs) != 0 {
+		return nil, false, fmt.Errorf("%w: %d nodes for %d branches", ErrMerkleProofMissingNodes, len(mp.Nodes), len(txs))
+	}
+	depth := len(mp.Nodes) / len(txs)
+
+	var (
+		root   []byte
+		isLast bool
+	)
+	for i, tx := range txs {
+		if depth < 64 && tx.Index >= 1<<depth {
+			return nil, false, fmt.Errorf("%w: index %d with %d nodes", ErrIndexOutOfRange, tx.Index, depth)
+		}
+		b := &merkleBranch{index: tx.Index, txid: txids[i], nodes: mp.Nodes[i*depth : (i+1)*depth]}
+		last := true
+		r, err := b.walk(func(level int, working, sibling []byte) {
+			if (b.index>>level)&1 == 0 && !bytes.Equal(working, sibling) {
+				last = false
+			}
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		if root != nil && !bytes.Equal(root, r) {
+			return nil, false, fmt.Errorf("%w: tx %s", ErrMerkleProofRootMismatch, txids[i])
+		}
+		root = r
+		isLast = isLast || last
+	}
+	return root, isLast, nil
+}
+
+// treeNode is a known node of a tree proof.
+type treeNode struct {
+	hash []byte
+	// isLast is set when the node is the last of its level.
+	isLast bool
+}
+
+// treeRoot hashes the transactions up to the root, taking the siblings it needs from
+// the nodes of the proof level by level.
+func (mp *MerkleProof) treeRoot(txs []MerkleProofTx, txids []string) ([]byte, bool, error) {
+	known := make(map[uint64]*treeNode, len(txs))
+	for i, tx := range txs {
+		if _, ok := known[tx.Index]; ok {
+			return nil, false, fmt.Errorf("%w: %d", ErrMerkleProofDuplicateIndex, tx.Index)
+		}
+		h, err := hashFromHex(txids[i])
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: txid %s", ErrInvalidMerkleProofNode, txids[i])
+		}
+		known[tx.Index] = &treeNode{hash: h, isLast: true}
+	}
+
+	next := 0
+	for level := 0; len(known) > 1 || next < len(mp.Nodes); level++ {
+		if level >= 64 {
+			return nil, false, fmt.Errorf("%w: more than 64 levels", ErrMerkleProofMissingNodes)
+		}
+		offsets := make([]uint64, 0, len(known))
+		for offset := range known {
+			offsets = append(offsets, offset)
+		}
+		sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+		parents := make(map[uint64]*treeNode, len(known))
+		for _, offset := range offsets {
+			if _, ok := parents[offset>>1]; ok {
+				// already hashed with its sibling.
+				continue
+			}
+			n := known[offset]
+			isRight := offset&1 == 1
+			sibling, ok := known[offset^1]
+			if !ok {
+				if next >= len(mp.Nodes) {
+					return nil, false, fmt.Errorf("%w: no sibling for offset %d at level %d", ErrMerkleProofMissingNodes, offset, level)
+				}
+				node := mp.Nodes[next]
+				next++
+				if node == duplicateNode {
+					if isRight {
+						return nil, false, fmt.Errorf("%w: duplicate on the left at level %d", ErrInvalidMerkleProofNode, level)
+					}
+					sibling = &treeNode{hash: n.hash, isLast: n.isLast}
+				} else {
+					h, err := hashFromHex(node)
+					if err != nil {
+						return nil, false, fmt.Errorf("%w: level %d", ErrInvalidMerkleProofNode, level)
+					}
+					// a sibling taken from the proof is never the one proven last.
+					sibling = &treeNode{hash: h}
+				}
+			}
+
+			left, right := n, sibling
+			if isRight {
+				left, right = sibling, n
+			}
+			parents[offset>>1] = &treeNode{
+				hash:   Sha256Sha256(append(append(make([]byte, 0, 64), left.hash...), right.hash...)),
+				isLast: right.isLast,
+			}
+		}
+		known = parents
+	}
+
+	// the levels are hashed until a single node is left, which is only the root if it's at
+	// offset 0, otherwise an index was beyond the width of the tree.
+	root, ok := known[0]
+	if !ok || len(known) != 1 {
+		return nil, false, fmt.Errorf("%w: no root at offset 0 of the tree", ErrIndexOutOfRange)
+	}
+	return root.hash, root.isLast, nil
+}
+
+// NewCompositeMerkleProofFromBUMP returns a composite tree MerkleProof of txids within
+// bump. As with NewMerkleProofFromBUMP, the proof targets the merkle root if hbc is nil,
+// otherwise the hash of the block at the BUMP's height.
+func NewCompositeMerkleProofFromBUMP(ctx context.Context, bump *BUMP, txids []string, hbc HeightBlockHeaderChain) (*MerkleProof, error) {
+	if len(txids) == 0 {
+		return nil, ErrMerkleProofNoTxs
+	}
+	if len(bump.Path) == 0 {
+		return nil, ErrInsufficientBUMPData
+	}
+
+	mp := &MerkleProof{ProofType: "tree", Composite: true, Nodes: []string{}}
+	positions := make(map[uint64]struct{}, len(txids))
+	for _, txid := range txids {
+		l, ok := bump.txidLeaf(txid)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTxidNotInBUMP, txid)
+		}
+		if _, ok = positions[*l.Offset]; ok {
+			return nil, fmt.Errorf("%w: %d", ErrMerkleProofDuplicateIndex, *l.Offset)
+		}
+		positions[*l.Offset] = struct{}{}
+		mp.Txs = append(mp.Txs, MerkleProofTx{Index: *l.Offset, TxOrID: txid})
+	}
+
+	// a block with a single transaction has no nodes.
+	if len(bump.Path) > 1 || len(bump.Path[0]) > 1 {
+		for height := range bump.Path {
+			offsets := make([]uint64, 0, len(positions))
+			for offset := range positions {
+				offsets = append(offsets, offset)
+			}
+			sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+			parents := make(map[uint64]struct{}, len(positions))
+			for _, offset := range offsets {
+				parents[offset>>1] = struct{}{}
+				if _, ok := positions[offset^1]; ok {
+					continue
+				}
+				sibling, err := bump.siblingLeaf(height, offset^1)
+				if err != nil {
+					return nil, err
+				}
+				if sibling.Duplicate != nil {
+					mp.Nodes = append(mp.Nodes, duplicateNode)
+				} else {
+					mp.Nodes = append(mp.Nodes, *sibling.Hash)
+				}
+			}
+			positions = parents
+		}
+	}
+
+	root, _, err := mp.CalculateRoot()
+	if err != nil {
+		return nil, err
+	}
+	if mp.Target, mp.TargetType, err = merkleProofTarget(ctx, bump, root, hbc); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
@@ -0,0 +1,182 @@
+package bc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeMerkleProofRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for size := 1; size <= 17; size++ {
+		txids, merkles := testBlock(t, size)
+		root := merkles[len(merkles)-1].String()
+
+		subsets := [][]uint64{{0}, {uint64(size - 1)}}
+		all := make([]uint64, 0, size)
+		for i := 0; i < size; i++ {
+			all = append(all, uint64(i))
+			for j := i + 1; j < size; j++ {
+				subsets = append(subsets, []uint64{uint64(i), uint64(j)})
+			}
+		}
+		subsets = append(subsets, all)
+
+		for _, indices := range subsets {
+			bump, err := NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, indices)
+			require.NoError(t, err)
+			proven := make([]string, 0, len(indices))
+			isLast := false
+			for _, i := range indices {
+				proven = append(proven, txids[i].String())
+				isLast = isLast || i == uint64(size-1)
+			}
+
+			proof, err := NewCompositeMerkleProofFromBUMP(ctx, bump, proven, nil)
+			require.NoErrorf(t, err, "size=%d indices=%v", size, indices)
+			require.Equal(t, root, proof.Target)
+			require.Equal(t, "merkleRoot", proof.TargetType)
+
+			calculated, last, err := proof.CalculateRoot()
+			require.NoErrorf(t, err, "size=%d indices=%v", size, indices)
+			require.Equalf(t, root, calculated, "size=%d indices=%v", size, indices)
+			require.Equalf(t, isLast, last, "size=%d indices=%v", size, indices)
+
+			b, err := proof.Bytes()
+			require.NoError(t, err)
+			decoded, err := NewMerkleProofFromBytes(b)
+			require.NoError(t, err)
+			require.Equal(t, proof, decoded)
+		}
+	}
+}
+
+func TestCompositeMerkleProofSingleTxMatchesBranch(t *testing.T) {
+	ctx := context.Background()
+	txids, merkles := testBlock(t, 11)
+	for i, txid := range txids {
+		bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, uint64(i))
+		require.NoError(t, err)
+
+		branch, err := NewMerkleProofFromBUMP(ctx, bump, txid.String(), nil)
+		require.NoError(t, err)
+		tree, err := NewCompositeMerkleProofFromBUMP(ctx, bump, []string{txid.String()}, nil)
+		require.NoError(t, err)
+		require.Equal(t, branch.Nodes, tree.Nodes)
+	}
+}
+
+func TestCompositeMerkleBranchProof(t *testing.T) {
+	ctx := context.Background()
+	txids, merkles := testBlock(t, 9)
+	root := merkles[len(merkles)-1].String()
+
+	proof := &MerkleProof{Composite: true, Target: root, TargetType: "merkleRoot"}
+	for _, i := range []uint64{2, 8} {
+		bump, err := NewBUMPFromMerkleTreeAndIndex(fakeMadeUpNum, merkles, i)
+		require.NoError(t, err)
+		branch, err := NewMerkleProofFromBUMP(ctx, bump, txids[i].String(), nil)
+		require.NoError(t, err)
+		proof.Txs = append(proof.Txs, MerkleProofTx{Index: i, TxOrID: txids[i].String()})
+		proof.Nodes = append(proof.Nodes, branch.Nodes...)
+	}
+
+	calculated, isLast, err := proof.CalculateRoot()
+	require.NoError(t, err)
+	require.Equal(t, root, calculated)
+	require.True(t, isLast)
+
+	b, err := proof.Bytes()
+	require.NoError(t, err)
+	decoded, err := NewMerkleProofFromBytes(b)
+	require.NoError(t, err)
+	require.Equal(t, proof, decoded)
+
+	proof.Txs[1].TxOrID = txids[7].String()
+	_, _, err = proof.CalculateRoot()
+	require.ErrorIs(t, err, ErrMerkleProofRootMismatch)
+
+	proof.Nodes = proof.Nodes[1:]
+	_, _, err = proof.CalculateRoot()
+	require.ErrorIs(t, err, ErrMerkleProofMissingNodes)
+}
+
+func TestCompositeMerkleProofErrors(t *testing.T) {
+	ctx := context.Background()
+	txids, merkles := testBlock(t, 7)
+	bump, err := NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, []uint64{1, 4})
+	require.NoError(t, err)
+	proven := []string{txids[1].String(), txids[4].String()}
+
+	tests := map[string]struct {
+		modify func(mp *MerkleProof)
+		err    error
+	}{
+		"missing node": {
+			modify: func(mp *MerkleProof) { mp.Nodes = mp.Nodes[:len(mp.Nodes)-1] },
+			err:    ErrMerkleProofMissingNodes,
+		},
+		"duplicate index": {
+			modify: func(mp *MerkleProof) { mp.Txs[1].Index = mp.Txs[0].Index },
+			err:    ErrMerkleProofDuplicateIndex,
+		},
+		"duplicate on the left": {
+			modify: func(mp *MerkleProof) { mp.Nodes[0] = duplicateNode },
+			err:    ErrInvalidMerkleProofNode,
+		},
+		"no txs": {
+			modify: func(mp *MerkleProof) { mp.Txs = nil },
+			err:    ErrMerkleProofNoTxs,
+		},
+		"unknown proof type": {
+			modify: func(mp *MerkleProof) { mp.ProofType = "forest" },
+			err:    ErrUnsupportedMerkleProof,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			proof, err := NewCompositeMerkleProofFromBUMP(ctx, bump, proven, nil)
+			require.NoError(t, err)
+			test.modify(proof)
+			_, _, err = proof.CalculateRoot()
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+
+	_, err = NewCompositeMerkleProofFromBUMP(ctx, bump, []string{txids[1].String(), txids[1].String()}, nil)
+	require.ErrorIs(t, err, ErrMerkleProofDuplicateIndex)
+	_, err = NewCompositeMerkleProofFromBUMP(ctx, bump, []string{txids[2].String()}, nil)
+	require.ErrorIs(t, err, ErrTxidNotInBUMP)
+}
+
+func TestCompositeMerkleProofBytesLayout(t *testing.T) {
+	txids, merkles := testBlock(t, 7)
+	bump, err := NewBUMPFromMerkleTreeAndIndices(fakeMadeUpNum, merkles, []uint64{1, 4})
+	require.NoError(t, err)
+	proof, err := NewCompositeMerkleProofFromBUMP(context.Background(), bump,
+		[]string{txids[1].String(), txids[4].String()}, nil)
+	require.NoError(t, err)
+
+	b, err := proof.Bytes()
+	require.NoError(t, err)
+	// flags, txCount, then the index and txid of each tx.
+	require.Equal(t, []byte{0x1c, 0x02, 0x01}, b[:3])
+	require.Equal(t, txids[1][:], b[3:35])
+	require.Equal(t, byte(0x04), b[35])
+	require.Equal(t, txids[4][:], b[36:68])
+	require.Equal(t, merkles[len(merkles)-1][:], b[68:100])
+	require.Equal(t, byte(len(proof.Nodes)), b[100])
+}
+
+func TestTreeMerkleProofIndexBeyondTree(t *testing.T) {
+	txids, _ := testBlock(t, 2)
+	proof := &MerkleProof{
+		ProofType: "tree",
+		Index:     4,
+		TxOrID:    txids[0].String(),
+		Nodes:     []string{txids[1].String(), txids[1].String()},
+	}
+	_, _, err := proof.CalculateRoot()
+	require.ErrorIs(t, err, ErrIndexOutOfRange)
+}
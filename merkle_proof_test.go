@@ -57,3 +57,138 @@ func TestMerkleProofBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMerkleProofFromBytesRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	const (
+		txid   = "ffeff11c25cde7c06d407490d81ef4d0db64aad6ab3d14393530701561a465ef"
+		tx     = "0100000001cd4e4cac3c7b56920d1e7655e7e260d31f29d9a388d04910f1bbd72304a79029010000006b483045022100e75279a205a547c445719420aa3138bf14743e3f42618e5f86a19bde14bb95f7022064777d34776b05d816daf1699493fcdf2ef5a5ab1ad710d9c97bfb5b8f7cef3641210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013e660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac00000000"
+		hash   = "75edb0a69eb195cdd81e310553aa4d25e18450e08f168532a2c2e9cf447bf169"
+		header = "000000208aef5325a07e4ec9cca864fca51e14d050d9fb9a371be6c651549580a0e33476414a38a7ddb819a4f3011cd06b17877968100a819348edb2009a60d0e0a65294fdf61361ffff7f2000000000"
+	)
+	nodes := []string{
+		"b9ef07a62553ef8b0898a79c291b92c60f7932260888bde0dab2dd2610d8668e",
+		"*",
+		"60b0e75dd5b8d48f2d069229f20399e07766dd651ceeed55ee3c040aa2812547",
+	}
+	manyNodes := make([]string, 300)
+	for i := range manyNodes {
+		manyNodes[i] = nodes[0]
+	}
+
+	tests := map[string]*bc.MerkleProof{
+		"txid with block hash target": {
+			Index: 4, TxOrID: txid, Target: hash, Nodes: nodes,
+		},
+		"txid with header target": {
+			Index: 4, TxOrID: txid, Target: header, TargetType: "header", Nodes: nodes,
+		},
+		"txid with merkle root target": {
+			Index: 4, TxOrID: txid, Target: hash, TargetType: "merkleRoot", Nodes: nodes,
+		},
+		"tx with block hash target": {
+			Index: 4, TxOrID: tx, Target: hash, Nodes: nodes,
+		},
+		"tx with header target": {
+			Index: 4, TxOrID: tx, Target: header, TargetType: "header", Nodes: nodes,
+		},
+		"tx with merkle root target": {
+			Index: 4, TxOrID: tx, Target: hash, TargetType: "merkleRoot", Nodes: nodes,
+		},
+		"no nodes": {
+			Index: 0, TxOrID: txid, Target: hash, TargetType: "merkleRoot", Nodes: []string{},
+		},
+		"more nodes than fit in a byte": {
+			Index: 1 << 40, TxOrID: txid, Target: hash, Nodes: manyNodes,
+		},
+	}
+
+	for name, proof := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := proof.Bytes()
+			require.NoError(t, err)
+
+			decoded, err := bc.NewMerkleProofFromBytes(b)
+			require.NoError(t, err)
+			require.Equal(t, proof, decoded)
+
+			decoded, err = bc.NewMerkleProofFromStr(hex.EncodeToString(b))
+			require.NoError(t, err)
+			require.Equal(t, proof, decoded)
+		})
+	}
+}
+
+func TestNewMerkleProofFromBytesErrors(t *testing.T) {
+	t.Parallel()
+
+	valid, err := (&bc.MerkleProof{
+		Index:  2,
+		TxOrID: "ffeff11c25cde7c06d407490d81ef4d0db64aad6ab3d14393530701561a465ef",
+		Target: "75edb0a69eb195cdd81e310553aa4d25e18450e08f168532a2c2e9cf447bf169",
+		Nodes: []string{
+			"b9ef07a62553ef8b0898a79c291b92c60f7932260888bde0dab2dd2610d8668e",
+			"*",
+		},
+	}).Bytes()
+	require.NoError(t, err)
+
+	with := func(i int, v byte) []byte {
+		b := append([]byte{}, valid...)
+		b[i] = v
+		return b
+	}
+
+	tests := map[string]struct {
+		bytes  []byte
+		expErr error
+	}{
+		"empty": {
+			expErr: bc.ErrTruncatedMerkleProof,
+		},
+		"truncated in the txid": {
+			bytes:  valid[:20],
+			expErr: bc.ErrTruncatedMerkleProof,
+		},
+		"truncated in a node": {
+			bytes:  valid[:len(valid)-10],
+			expErr: bc.ErrTruncatedMerkleProof,
+		},
+		"missing node": {
+			bytes:  valid[:len(valid)-1],
+			expErr: bc.ErrTruncatedMerkleProof,
+		},
+		"both target type flags": {
+			bytes:  with(0, 0x06),
+			expErr: bc.ErrInvalidMerkleFlags,
+		},
+		"unknown flags": {
+			bytes:  with(0, 0x40),
+			expErr: bc.ErrInvalidMerkleFlags,
+		},
+		"short tx": {
+			bytes:  append([]byte{0x01, 0x00, 0x20}, valid[2:]...),
+			expErr: bc.ErrInvalidMerkleProofTxLength,
+		},
+		"index beyond the nodes": {
+			bytes:  with(1, 0x04),
+			expErr: bc.ErrIndexOutOfRange,
+		},
+		"unknown node type": {
+			bytes:  with(len(valid)-1, 0x02),
+			expErr: bc.ErrInvalidMerkleProofNodeType,
+		},
+		"trailing data": {
+			bytes:  append(append([]byte{}, valid...), 0x00),
+			expErr: bc.ErrMerkleProofTrailingData,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := bc.NewMerkleProofFromBytes(test.bytes)
+			require.ErrorIs(t, err, test.expErr)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package bc
+
+import (
+	"encoding/hex"
+	"math"
+	"math/big"
+	"time"
+)
+
+// A Checkpoint is the hash of the block at a height, which a chain must include.
+type Checkpoint struct {
+	Height uint64
+	Hash   string
+}
+
+// Params are the consensus parameters of a network, which the difficulty, header chain
+// and block validation code is given instead of a coin ticker.
+//
+// Activation heights follow the node: the UAHF and DAA rules apply to the blocks after
+// UAHFHeight and DAAHeight, while the Genesis rules apply from GenesisHeight itself.
+type Params struct {
+	// Name is the name of the network.
+	Name string
+	// GenesisHeader is the hex encoded header of the block at height 0.
+	GenesisHeader string
+	// PowLimit is the highest, so easiest, target a header may have.
+	PowLimit *big.Int
+	// TargetTimespan is the time the original difficulty adjustment aims for each
+	// retarget interval to take.
+	TargetTimespan time.Duration
+	// TargetSpacing is the time aimed for between blocks.
+	TargetSpacing time.Duration
+	// AllowMinDifficultyBlocks allows a block to have the PowLimit target when it is
+	// over twice TargetSpacing after its parent.
+	AllowMinDifficultyBlocks bool
+	// NoRetargeting keeps the target the same as the parent's at every height.
+	NoRetargeting bool
+	// BIP34Height is the height from which coinbases must start with the block height.
+	BIP34Height uint64
+	// UAHFHeight is the height of the last block before the August 2017 fork, after
+	// which the emergency difficulty adjustment applies.
+	UAHFHeight uint64
+	// DAAHeight is the height of the last block before the November 2017 fork, after
+	// which the CW-144 difficulty adjustment applies.
+	DAAHeight uint64
+	// GenesisHeight is the height the Genesis upgrade activates at.
+	GenesisHeight uint64
+	// Checkpoints are blocks the chain must include, in order of height.
+	Checkpoints []Checkpoint
+}
+
+// powLimit parses the hex of a proof-of-work limit.
+func powLimit(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid pow limit " + s)
+	}
+	return n
+}
+
+// MainNetParams are the consensus parameters of the BSV main network.
+var MainNetParams = Params{
+	Name:           "mainnet",
+	GenesisHeader:  "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c",
+	PowLimit:       powLimit("00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	TargetTimespan: 14 * 24 * time.Hour,
+	TargetSpacing:  10 * time.Minute,
+	BIP34Height:    227931,
+	UAHFHeight:     478558,
+	DAAHeight:      504031,
+	GenesisHeight:  620538,
+	Checkpoints: []Checkpoint{
+		{Height: 11111, Hash: "0000000069e244f73d78e8fd29ba2fd2ed618bd6fa2ee92559f542fdb26e7c1d"},
+		{Height: 33333, Hash: "000000002dd5588a74784eaa7ab0507a18ad16a236e7b1ce69f00d7ddfb5d0a6"},
+		{Height: 74000, Hash: "0000000000573993a3c9e41ce34471c079dcf5f52a0e824a81e7f953b8661a20"},
+		{Height: 105000, Hash: "00000000000291ce28027faea320c8d2b054b2e0fe44a773f3eefb151d6bdc97"},
+		{Height: 134444, Hash: "00000000000005b12ffd4cd315cd34ffd4a594f430ac814c91184a0d42d2b0fe"},
+		{Height: 168000, Hash: "000000000000099e61ea72015e79632f216fe6cb33d7899acb35b75c8303b763"},
+		{Height: 193000, Hash: "000000000000059f452a5f7340de6682a977387c17010ff6e6c3bd83ca8b1317"},
+		{Height: 210000, Hash: "000000000000048b95347e83192f69cf0366076336c639f9b7228e9ba171342e"},
+		{Height: 216116, Hash: "00000000000001b4f4b433e81ee46494af945cf96014816a4e2370f11b23df4e"},
+		{Height: 225430, Hash: "00000000000001c108384350f74090433e7fcf79a606b8e797f065b130575932"},
+		{Height: 250000, Hash: "000000000000003887df1f29024b06fc2200b55f8af8f35453d7be294df2d214"},
+		{Height: 279000, Hash: "0000000000000001ae8c72a0b0c301f67e3afca10e819efa9041e458e9bd7e40"},
+		{Height: 295000, Hash: "00000000000000004d9b4ef50f0f9d686fd69db2e03af35a100370c64632a983"},
+		{Height: 478558, Hash: "0000000000000000011865af4122fe3b144e2cbeea86142e8ff2fb4107352d43"},
+		{Height: 556767, Hash: "000000000000000001d956714215d96ffc00e0afda4cd0a96c96f8d802b1662b"},
+	},
+}
+
+// TestNetParams are the consensus parameters of the BSV test network.
+var TestNetParams = Params{
+	Name:                     "testnet",
+	GenesisHeader:            "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4adae5494dffff001d1aa4ae18",
+	PowLimit:                 powLimit("00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	TargetTimespan:           14 * 24 * time.Hour,
+	TargetSpacing:            10 * time.Minute,
+	AllowMinDifficultyBlocks: true,
+	BIP34Height:              21111,
+	UAHFHeight:               1155875,
+	DAAHeight:                1188697,
+	GenesisHeight:            1344302,
+	Checkpoints: []Checkpoint{
+		{Height: 546, Hash: "000000002a936ca763904c3c35fce2f3556c559c0214345d31b1bcebf76acb70"},
+	},
+}
+
+// STNParams are the consensus parameters of the BSV scaling test network.
+var STNParams = Params{
+	Name:                     "stn",
+	GenesisHeader:            TestNetParams.GenesisHeader,
+	PowLimit:                 powLimit("00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	TargetTimespan:           14 * 24 * time.Hour,
+	TargetSpacing:            10 * time.Minute,
+	AllowMinDifficultyBlocks: true,
+	BIP34Height:              100,
+	UAHFHeight:               15,
+	DAAHeight:                2200,
+	GenesisHeight:            100,
+}
+
+// RegTestParams are the consensus parameters of a local regression test network.
+var RegTestParams = Params{
+	Name:                     "regtest",
+	GenesisHeader:            "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4adae5494dffff7f2002000000",
+	PowLimit:                 powLimit("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	TargetTimespan:           14 * 24 * time.Hour,
+	TargetSpacing:            10 * time.Minute,
+	AllowMinDifficultyBlocks: true,
+	NoRetargeting:            true,
+	BIP34Height:              100000000,
+	GenesisHeight:            10000,
+}
+
+// GenesisBlockHeader returns the header of the block at height 0.
+func (p *Params) GenesisBlockHeader() (*BlockHeader, error) {
+	return NewBlockHeaderFromStr(p.GenesisHeader)
+}
+
+// PowLimitBits returns the compact encoding of PowLimit, in the byte order of
+// BlockHeader.Bits.
+func (p *Params) PowLimitBits() []byte {
+	return TargetToBits(p.PowLimit)
+}
+
+// Checkpoint returns the hash of the checkpointed block at height, if there is one.
+func (p *Params) Checkpoint(height uint64) (string, bool) {
+	for _, c := range p.Checkpoints {
+		if c.Height == height {
+			return c.Hash, true
+		}
+	}
+	return "", false
+}
+
+// IsUAHFEnabled reports whether the block at height follows the August 2017 fork.
+func (p *Params) IsUAHFEnabled(height uint64) bool {
+	return height > p.UAHFHeight
+}
+
+// IsDAAEnabled reports whether the block at height follows the November 2017 fork, and
+// so the CW-144 difficulty adjustment.
+func (p *Params) IsDAAEnabled(height uint64) bool {
+	return height > p.DAAHeight
+}
+
+// IsGenesisEnabled reports whether the Genesis upgrade is active at height.
+func (p *Params) IsGenesisEnabled(height uint64) bool {
+	return height >= p.GenesisHeight
+}
+
+// DifficultyToHashrate computes the estimated hashrate of the network from its
+// difficulty and the target seconds between blocks.
+func (p *Params) DifficultyToHashrate(diff uint64, targetSeconds float64) float64 {
+	// difficulty 1 is the PowLimit target, which takes 2^256 / target hashes to find.
+	target, _ := ExpandTargetFromAsInt(hex.EncodeToString(p.PowLimitBits()))
+	bf, _ := new(big.Float).SetInt(target).Float64()
+	return float64(diff) * (math.Pow(2, 256) / bf) / targetSeconds
+}
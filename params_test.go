@@ -0,0 +1,77 @@
+package bc_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+func TestParamsGenesis(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		params *bc.Params
+		hash   string
+		bits   string
+	}{
+		"mainnet": {
+			params: &bc.MainNetParams,
+			hash:   "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+			bits:   "1d00ffff",
+		},
+		"testnet": {
+			params: &bc.TestNetParams,
+			hash:   "000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943",
+			bits:   "1d00ffff",
+		},
+		"stn": {
+			params: &bc.STNParams,
+			hash:   "000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943",
+			bits:   "1d00ffff",
+		},
+		"regtest": {
+			params: &bc.RegTestParams,
+			hash:   "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206",
+			bits:   "207fffff",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			genesis, err := test.params.GenesisBlockHeader()
+			require.NoError(t, err)
+			require.Equal(t, test.hash, genesis.HashStr())
+			require.True(t, genesis.Valid())
+			require.Equal(t, test.bits, hex.EncodeToString(test.params.PowLimitBits()))
+			require.Equal(t, test.bits, genesis.BitsStr())
+		})
+	}
+}
+
+func TestParamsActivation(t *testing.T) {
+	t.Parallel()
+
+	p := &bc.MainNetParams
+	require.False(t, p.IsUAHFEnabled(478558))
+	require.True(t, p.IsUAHFEnabled(478559))
+	require.False(t, p.IsDAAEnabled(504031))
+	require.True(t, p.IsDAAEnabled(504032))
+	require.False(t, p.IsGenesisEnabled(620537))
+	require.True(t, p.IsGenesisEnabled(620538))
+
+	hash, ok := p.Checkpoint(478558)
+	require.True(t, ok)
+	require.Equal(t, "0000000000000000011865af4122fe3b144e2cbeea86142e8ff2fb4107352d43", hash)
+	_, ok = p.Checkpoint(478559)
+	require.False(t, ok)
+}
+
+func TestParamsDifficultyToHashrate(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "13.50 TH/s", bc.HumanHash(bc.MainNetParams.DifficultyToHashrate(22000, 7)))
+	require.Equal(t, "6.29 kH/s", bc.HumanHash(bc.RegTestParams.DifficultyToHashrate(22000, 7)))
+	require.Equal(t, bc.MainNetParams.DifficultyToHashrate(22000, 7), bc.DifficultyToHashrate("BSV", 22000, 7))
+}
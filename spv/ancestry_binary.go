@@ -6,8 +6,10 @@ package spv
 
 import (
 	"github.com/bsv-blockchain/go-bt/v2"
-	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/bscript/interpreter"
+	"github.com/bsv-blockchain/go-bt/v2/bscript/interpreter/scriptflag"
 	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+	"github.com/pkg/errors"
 
 	"github.com/bsv-blockchain/go-bc"
 )
@@ -19,9 +21,13 @@ const (
 )
 
 // Payment is a payment and its ancestry.
+//
+// The ancestry can be supplied either in the TSC binary format as Ancestry or,
+// alternatively, as a BRC-62 Beef, in which case Ancestry is ignored.
 type Payment struct {
 	PaymentTx *bt.Tx
 	Ancestry  []byte
+	Beef      *Beef
 }
 
 // binaryChunk is a clear way to pass around chunks while keeping their type explicit.
@@ -38,36 +44,56 @@ type extendedInput struct {
 type ancestry struct {
 	Tx            *bt.Tx
 	Proof         []byte
+	BUMP          *bc.BUMP
 	MapiResponses []*bc.MapiCallback
 }
 
+// previousTxID returns the txid spent by the input in the same byte order
+// used to key the ancestry map returned by parseAncestry.
+func previousTxID(input *bt.Input) [32]byte {
+	var id [32]byte
+	copy(id[:], bt.ReverseBytes(input.PreviousTxID()))
+	return id
+}
+
 // parseAncestry creates a new struct from the bytes of a txContext.
-func parseAncestry(b []byte) (map[[32]byte]*ancestry, error) {
+//
+// The bytes come from untrusted peers so every length is checked before it is used, returning
+// ErrTruncatedAncestry, ErrOversizedVarInt, ErrOrphanChunk or ErrUnknownChunkType on malformed input,
+// and ErrLimitExceeded as soon as the ancestry exceeds one of the Limits l.
+func parseAncestry(b []byte, l *Limits) (map[[32]byte]*ancestry, error) {
+	if len(b) == 0 {
+		return nil, ErrTruncatedAncestry
+	}
+	if err := l.checkBytes(b); err != nil {
+		return nil, err
+	}
 	if b[0] != 1 { // the first byte is the version number.
 		return nil, ErrUnsupporredVersion
 	}
-	offset := uint64(1)
-	total := uint64(len(b))
+	offset := 1
+	total := len(b)
 	aa := make(map[[32]byte]*ancestry)
 
-	var TxID [32]byte
-
 	if total == offset {
 		return nil, ErrCannotCalculateFeePaid
 	}
 
-	// first Data must be a Tx
-	if b[offset] != 1 {
-		return nil, ErrTipTxConfirmed
-	}
-
+	// current is the tx which proof and mapi chunks belong to, the first Data must be a Tx.
+	var current *ancestry
+	var nMapi int
+	depths := l.newDepthTracker()
 	for total > offset {
-		chunk, size := parseChunk(b, offset)
+		chunk, size, err := parseChunk(b, offset)
+		if err != nil {
+			return nil, err
+		}
 		offset += size
 		switch chunk.ContentType {
 		case flagTx:
+			var txID [32]byte
 			hash := crypto.Sha256d(chunk.Data)
-			copy(TxID[:], bt.ReverseBytes(hash)) // fixed size array from slice.
+			copy(txID[:], bt.ReverseBytes(hash)) // fixed size array from slice.
 			tx, err := bt.NewTxFromBytes(chunk.Data)
 			if err != nil {
 				return nil, err
@@ -75,58 +101,100 @@ func parseAncestry(b []byte) (map[[32]byte]*ancestry, error) {
 			if len(tx.Inputs) == 0 {
 				return nil, ErrNoTxInputsToVerify
 			}
-			aa[TxID] = &ancestry{
+			current = &ancestry{
 				Tx: tx,
 			}
+			aa[txID] = current
+			if err := l.checkTxs(len(aa)); err != nil {
+				return nil, err
+			}
+			if err := depths.add(txID, tx); err != nil {
+				return nil, err
+			}
 		case flagProof:
-			aa[TxID].Proof = chunk.Data
+			if current == nil {
+				return nil, errors.Wrap(ErrOrphanChunk, "proof")
+			}
+			if l.MaxProofNodes > 0 {
+				mp, err := bc.NewMerkleProofFromBytes(chunk.Data)
+				if err != nil {
+					return nil, err
+				}
+				if err := l.checkProofNodes(len(mp.Nodes)); err != nil {
+					return nil, err
+				}
+			}
+			current.Proof = chunk.Data
 		case flagMapi:
+			if current == nil {
+				return nil, errors.Wrap(ErrOrphanChunk, "mapi")
+			}
 			callBacks, err := parseMapiCallbacks(chunk.Data)
 			if err != nil {
 				return nil, err
 			}
-			aa[TxID].MapiResponses = callBacks
+			nMapi += len(callBacks)
+			if err := l.checkMapiCallbacks(nMapi); err != nil {
+				return nil, err
+			}
+			current.MapiResponses = callBacks
 		default:
-			continue
+			return nil, errors.Wrapf(ErrUnknownChunkType, "type %d at offset %d", chunk.ContentType, offset-size)
 		}
 	}
 	return aa, nil
 }
 
-func parseChunk(b []byte, start uint64) (binaryChunk, uint64) {
+// parseChunk reads the type, length and data of the chunk starting at start,
+// returning the chunk and the number of bytes it used.
+func parseChunk(b []byte, start int) (binaryChunk, int, error) {
 	offset := start
+	if offset >= len(b) {
+		return binaryChunk{}, 0, ErrTruncatedAncestry
+	}
 	typeOfNextData := b[offset]
 	offset++
-	l, size := bt.NewVarIntFromBytes(b[offset:])
-	// Safe conversion: size from VarInt is bounded by max varint size (9 bytes)
-	offset += uint64(size) //nolint:gosec // G115: Safe conversion - size is bounded by VarInt max size (9 bytes)
+	l, size, ok := bc.ReadVarInt(b, offset)
+	if !ok {
+		return binaryChunk{}, 0, ErrTruncatedAncestry
+	}
+	offset += size
+	if l > uint64(len(b)-offset) {
+		return binaryChunk{}, 0, errors.Wrapf(ErrOversizedVarInt, "chunk length %d at offset %d", l, start)
+	}
 	chunk := binaryChunk{
 		ContentType: typeOfNextData,
-		Data:        b[offset : offset+uint64(l)],
+		Data:        b[offset : offset+int(l)], //nolint:gosec // G115: Safe conversion - l is bounded by len(b)
 	}
-	offset += uint64(l)
-	return chunk, offset - start
+	offset += int(l) //nolint:gosec // G115: Safe conversion - l is bounded by len(b)
+	return chunk, offset - start, nil
 }
 
 func parseMapiCallbacks(b []byte) ([]*bc.MapiCallback, error) {
 	if len(b) == 0 {
 		return nil, ErrTriedToParseZeroBytes
 	}
-	var internalOffset uint64
-	allBinary := uint64(len(b))
-	numOfMapiResponses := b[internalOffset]
-	if numOfMapiResponses == 0 && len(b) == 1 {
+	allBinary := len(b)
+	numOfMapiResponses, internalOffset, ok := bc.ReadVarInt(b, 0)
+	if !ok {
+		return nil, ErrTruncatedAncestry
+	}
+	if numOfMapiResponses == 0 && len(b) == internalOffset {
 		return nil, ErrTriedToParseZeroBytes
 	}
-	internalOffset++
 
 	responses := [][]byte{}
 	for allBinary > internalOffset {
-		l, size := bt.NewVarIntFromBytes(b[internalOffset:])
-		// Safe conversion: size from VarInt is bounded by max varint size (9 bytes)
-		internalOffset += uint64(size) //nolint:gosec // G115: Safe conversion - size is bounded by VarInt max size (9 bytes)
-		response := b[internalOffset : internalOffset+uint64(l)]
-		internalOffset += uint64(l)
+		l, size, ok := bc.ReadVarInt(b, internalOffset)
+		if !ok {
+			return nil, ErrTruncatedAncestry
+		}
+		internalOffset += size
+		if l > uint64(allBinary-internalOffset) {
+			return nil, errors.Wrapf(ErrOversizedVarInt, "mapi response length %d", l)
+		}
+		response := b[internalOffset : internalOffset+int(l)] //nolint:gosec // G115: Safe conversion - l is bounded by len(b)
+		internalOffset += int(l)                              //nolint:gosec // G115: Safe conversion - l is bounded by len(b)
 		responses = append(responses, response)
 	}
 
@@ -141,7 +209,19 @@ func parseMapiCallbacks(b []byte) ([]*bc.MapiCallback, error) {
 	return mapiResponses, nil
 }
 
-func verifyInputOutputPair(_ *bt.Tx, _, _ *bscript.Script) bool {
-	// TODO script interpreter.
-	return true
+// verifyInputOutputPair executes the unlocking script of input vin of tx against the
+// locking script of prevOutput, the output it spends, returning a *ScriptError if
+// the scripts do not evaluate successfully.
+func verifyInputOutputPair(tx *bt.Tx, vin int, prevOutput *bt.Output, flags scriptflag.Flag) error {
+	if err := interpreter.NewEngine().Execute(
+		interpreter.WithTx(tx, vin, prevOutput),
+		interpreter.WithFlags(flags),
+	); err != nil {
+		return &ScriptError{
+			TxID: tx.TxID(),
+			Vin:  vin,
+			Err:  err,
+		}
+	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package spv
+
+import (
+	"testing"
+)
+
+// FuzzParseAncestry ensures parseAncestry never panics on malformed input and
+// that every tx in a successfully parsed ancestry is populated.
+func FuzzParseAncestry(f *testing.F) {
+	for _, file := range []string{"valid.json", "valid_deep.json", "invalid_tx_indexing_oob.json"} {
+		b, rawTx := loadAncestryBytes(f, file)
+		f.Add(b)
+		f.Add(append([]byte{0x01}, txChunk(rawTx)...))
+	}
+	f.Add([]byte{})
+	f.Add([]byte{0x01})
+	f.Add([]byte{0x01, flagProof, 0x01, 0x00})
+	f.Add([]byte{0x01, flagMapi, 0x01, 0x00})
+	f.Add([]byte{0x01, flagTx, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		aa, err := parseAncestry(b, &Limits{})
+		if err != nil {
+			return
+		}
+		for txID, a := range aa {
+			if a == nil || a.Tx == nil {
+				t.Fatalf("nil tx for %x", txID)
+			}
+		}
+	})
+}
+
+// FuzzParseMapiCallbacks ensures parseMapiCallbacks never panics on malformed input.
+func FuzzParseMapiCallbacks(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x00})
+	f.Add([]byte{0x01, 0x00})
+	f.Add([]byte{0x01, 0x05, 0x01})
+	f.Add([]byte{0xfe, 0x01})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _ = parseMapiCallbacks(b)
+	})
+}
+
+// FuzzNewSpecialKEnvelopeFromBytes ensures NewSpecialKEnvelopeFromBytes never panics or hangs on malformed input.
+func FuzzNewSpecialKEnvelopeFromBytes(f *testing.F) {
+	_, rawTx := loadAncestryBytes(f, "valid.json")
+	f.Add(specialKEnvelope(rawTx, nil, nil))
+	f.Add(specialKEnvelope(rawTx, []byte{0x01, 0x00, 0x21, 0xff}, []byte{0x01, 0x09, 0x00}))
+	f.Add([]byte{0x01, 0x05, 0x01})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _ = NewSpecialKEnvelopeFromBytes(b)
+	})
+}
+
+// FuzzNewCrunchyNutEnvelopeFromBytes ensures NewCrunchyNutEnvelopeFromBytes never panics on malformed input.
+func FuzzNewCrunchyNutEnvelopeFromBytes(f *testing.F) {
+	_, rawTx := loadAncestryBytes(f, "valid.json")
+	f.Add(append([]byte{0x01}, txChunk(rawTx)...))
+	f.Add([]byte{0x01, flagProof, 0x01, 0x00})
+	f.Add([]byte{0x01, 0x07, 0x01, 0x00})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _ = NewCrunchyNutEnvelopeFromBytes(b)
+	})
+}
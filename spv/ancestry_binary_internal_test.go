@@ -0,0 +1,124 @@
+package spv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc/testing/data"
+)
+
+// loadAncestryBytes returns the binary ancestry and payment rawtx of a spv verify fixture.
+func loadAncestryBytes(tb testing.TB, file string) ([]byte, []byte) {
+	tb.Helper()
+	testData := struct {
+		Envelope *AncestryJSON `json:"data"`
+	}{}
+	bb, err := data.SpvVerifyData.Load(file)
+	require.NoError(tb, err)
+	require.NoError(tb, json.NewDecoder(bytes.NewBuffer(bb)).Decode(&testData))
+
+	b, err := testData.Envelope.Bytes()
+	require.NoError(tb, err)
+	rawTx, err := hex.DecodeString(testData.Envelope.RawTx)
+	require.NoError(tb, err)
+	return b, rawTx
+}
+
+// txChunk returns a flagTx chunk holding rawTx.
+func txChunk(rawTx []byte) []byte {
+	b := []byte{flagTx}
+	b = append(b, bt.VarInt(uint64(len(rawTx))).Bytes()...)
+	return append(b, rawTx...)
+}
+
+func TestParseAncestry_Malformed(t *testing.T) {
+	valid, rawTx := loadAncestryBytes(t, "valid_deep.json")
+	tx := txChunk(rawTx)
+
+	tests := map[string]struct {
+		b      []byte
+		expErr error
+	}{
+		"empty input": {
+			b:      []byte{},
+			expErr: ErrTruncatedAncestry,
+		},
+		"nil input": {
+			b:      nil,
+			expErr: ErrTruncatedAncestry,
+		},
+		"unsupported version": {
+			b:      []byte{0x02, flagTx, 0x00},
+			expErr: ErrUnsupporredVersion,
+		},
+		"version only": {
+			b:      []byte{0x01},
+			expErr: ErrCannotCalculateFeePaid,
+		},
+		"chunk type without length": {
+			b:      []byte{0x01, flagTx},
+			expErr: ErrTruncatedAncestry,
+		},
+		"varint prefix without value": {
+			b:      []byte{0x01, flagTx, 0xfd, 0x01},
+			expErr: ErrTruncatedAncestry,
+		},
+		"length beyond remaining bytes": {
+			b:      []byte{0x01, flagTx, 0x05, 0x01, 0x02},
+			expErr: ErrOversizedVarInt,
+		},
+		"huge uint64 length": {
+			b:      []byte{0x01, flagTx, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			expErr: ErrOversizedVarInt,
+		},
+		"truncated valid ancestry": {
+			b:      valid[:len(valid)-10],
+			expErr: ErrOversizedVarInt,
+		},
+		"proof before any tx": {
+			b:      []byte{0x01, flagProof, 0x02, 0xaa, 0xbb},
+			expErr: ErrOrphanChunk,
+		},
+		"mapi before any tx": {
+			b:      []byte{0x01, flagMapi, 0x02, 0x01, 0x00},
+			expErr: ErrOrphanChunk,
+		},
+		"unknown chunk type": {
+			b:      append(append([]byte{0x01}, tx...), 0x07, 0x01, 0x00),
+			expErr: ErrUnknownChunkType,
+		},
+		"mapi chunk with truncated count": {
+			b:      append(append([]byte{0x01}, tx...), flagMapi, 0x02, 0xfe, 0x01),
+			expErr: ErrTruncatedAncestry,
+		},
+		"mapi response longer than chunk": {
+			b:      append(append([]byte{0x01}, tx...), flagMapi, 0x03, 0x01, 0x09, 0x00),
+			expErr: ErrOversizedVarInt,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NotPanics(t, func() {
+				aa, err := parseAncestry(test.b, &Limits{})
+				require.ErrorIs(t, err, test.expErr)
+				require.Nil(t, aa)
+			})
+		})
+	}
+}
+
+func TestParseAncestry_Valid(t *testing.T) {
+	valid, _ := loadAncestryBytes(t, "valid_deep.json")
+
+	aa, err := parseAncestry(valid, &Limits{})
+	require.NoError(t, err)
+	require.NotEmpty(t, aa)
+	for _, a := range aa {
+		require.NotNil(t, a.Tx)
+	}
+}
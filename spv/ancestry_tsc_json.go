@@ -25,9 +25,10 @@ type TSCAncestryJSON struct {
 	MapiResponses []*bc.MapiCallback `json:"mapiResponses,omitempty"`
 }
 
-// NewAncestryJSONFromBytes is a way to create the JSON format for Ancestry from the binary format.
-func NewAncestryJSONFromBytes(b []byte) (TSCAncestriesJSON, error) {
-	ancestry, err := parseAncestry(b)
+// NewAncestryJSONFromBytes is a way to create the JSON format for Ancestry from the binary format,
+// returning ErrLimitExceeded if it exceeds any Limits set by opts.
+func NewAncestryJSONFromBytes(b []byte, opts ...ParseOpt) (TSCAncestriesJSON, error) {
+	ancestry, err := parseAncestry(b, newLimits(opts...))
 	if err != nil {
 		return nil, err
 	}
@@ -39,17 +40,10 @@ func NewAncestryJSONFromBytes(b []byte) (TSCAncestriesJSON, error) {
 			MapiResponses: ancestor.MapiResponses,
 		}
 		if ancestor.Proof != nil {
-			mpb, err := parseBinaryMerkleProof(ancestor.Proof)
+			a.Proof, err = bc.NewMerkleProofFromBytes(ancestor.Proof)
 			if err != nil {
 				return nil, err
 			}
-			a.Proof = &bc.MerkleProof{
-				Index:     mpb.index,
-				TxOrID:    mpb.txOrID,
-				Target:    mpb.target,
-				Nodes:     mpb.nodes,
-				ProofType: flagProofType(mpb.flags),
-			}
 		}
 		ancestors = append(ancestors, a)
 	}
@@ -101,17 +95,3 @@ func (j TSCAncestriesJSON) Bytes() ([]byte, error) {
 
 	return binaryTxContext, nil
 }
-
-func flagProofType(flags byte) string {
-	switch flags & targetTypeFlags {
-	// if bits 1 and 2 of flags are NOT set, target should contain a block hash (32 bytes).
-	// if bit 2 of flags is set, the target should contain a merkle root (32 bytes).
-	case 0, 4:
-		return "blockhash"
-	// if bit 1 of flags is set, the target should contain a block header (80 bytes).
-	case 2:
-		return h
-	default:
-		return ""
-	}
-}
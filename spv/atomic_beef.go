@@ -0,0 +1,160 @@
+package spv
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/pkg/errors"
+)
+
+// AtomicBeefPrefix is the marker which prefixes every Atomic BEEF, written as
+// little endian bytes 01010101.
+const AtomicBeefPrefix = uint32(0x01010101)
+
+/*
+| Field        | Purpose                                                          | Size (Bytes) |
+|--------------|------------------------------------------------------------------|--------------|
+| prefix       | AtomicBeefPrefix as uint32 little endian                         | 4            |
+| subject txid | TxID of the transaction the BEEF is about, in internal byte order| 32           |
+| beef         | The BEEF in BRC-62 binary format                                 | -            |
+*/
+
+// AtomicBeef is a Beef which is only about a single subject transaction, encoded according
+// to BRC-95 https://brc.dev/95.
+//
+// Every other transaction in the Beef must be an ancestor of the subject, so a bundle built
+// for one payment cannot be used to smuggle unrelated transactions.
+type AtomicBeef struct {
+	SubjectTxID string
+	Beef        *Beef
+}
+
+// NewAtomicBeef wraps the Beef as an AtomicBeef about its last transaction, which is
+// the payment transaction when the Beef is in dependency order.
+func NewAtomicBeef(beef *Beef) (*AtomicBeef, error) {
+	if len(beef.Txs) == 0 {
+		return nil, ErrAtomicBeefSubjectMissing
+	}
+	return &AtomicBeef{
+		SubjectTxID: beef.Txs[len(beef.Txs)-1].Tx.TxID(),
+		Beef:        beef,
+	}, nil
+}
+
+// NewAtomicBeefFromBytes parses a BRC-95 Atomic BEEF byte slice into the AtomicBeef structure.
+//
+// The relationship between the subject and the other transactions is not checked here, use
+// Validate or PaymentVerifier.VerifyAtomicBeef to enforce it.
+func NewAtomicBeefFromBytes(b []byte, opts ...ParseOpt) (*AtomicBeef, error) {
+	if len(b) < 36 {
+		return nil, ErrTruncatedBeef
+	}
+	if binary.LittleEndian.Uint32(b[:4]) != AtomicBeefPrefix {
+		return nil, ErrNotAtomicBeef
+	}
+
+	beef, err := NewBeefFromBytes(b[36:], opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AtomicBeef{
+		SubjectTxID: hex.EncodeToString(bt.ReverseBytes(b[4:36])),
+		Beef:        beef,
+	}, nil
+}
+
+// NewAtomicBeefFromStr parses a BRC-95 Atomic BEEF hex string into the AtomicBeef structure.
+func NewAtomicBeefFromStr(str string, opts ...ParseOpt) (*AtomicBeef, error) {
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+	return NewAtomicBeefFromBytes(b, opts...)
+}
+
+// Bytes encodes the AtomicBeef in BRC-95 binary format.
+func (a *AtomicBeef) Bytes() ([]byte, error) {
+	subject, err := hex.DecodeString(a.SubjectTxID)
+	if err != nil {
+		return nil, err
+	}
+	if len(subject) != 32 {
+		return nil, ErrInvalidTxOrIDLength
+	}
+
+	beef, err := a.Beef.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	bytes := make([]byte, 4, 36+len(beef))
+	binary.LittleEndian.PutUint32(bytes, AtomicBeefPrefix)
+	bytes = append(bytes, bt.ReverseBytes(subject)...)
+	bytes = append(bytes, beef...)
+
+	return bytes, nil
+}
+
+// String encodes the AtomicBeef as a hex string.
+func (a *AtomicBeef) String() (string, error) {
+	bytes, err := a.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// SubjectTx returns the subject transaction from the Beef, or nil if it is not present.
+func (a *AtomicBeef) SubjectTx() *bt.Tx {
+	for _, beefTx := range a.Beef.Txs {
+		if beefTx.Tx.TxID() == a.SubjectTxID {
+			return beefTx.Tx
+		}
+	}
+	return nil
+}
+
+// Validate ensures the subject transaction is present in the Beef and every other
+// transaction is one of its ancestors.
+func (a *AtomicBeef) Validate() error {
+	txs := make(map[string]*bt.Tx, len(a.Beef.Txs))
+	for _, beefTx := range a.Beef.Txs {
+		txs[beefTx.Tx.TxID()] = beefTx.Tx
+	}
+
+	subject, ok := txs[a.SubjectTxID]
+	if !ok {
+		return errors.Wrapf(ErrAtomicBeefSubjectMissing, "subject %s", a.SubjectTxID)
+	}
+
+	// walk back from the subject through every input we have the parent of.
+	related := map[string]struct{}{a.SubjectTxID: {}}
+	queue := []*bt.Tx{subject}
+	for len(queue) > 0 {
+		tx := queue[0]
+		queue = queue[1:]
+		for _, input := range tx.Inputs {
+			parentID := input.PreviousTxIDStr()
+			if _, ok := related[parentID]; ok {
+				continue
+			}
+			parent, ok := txs[parentID]
+			if !ok {
+				continue
+			}
+			related[parentID] = struct{}{}
+			queue = append(queue, parent)
+		}
+	}
+
+	for _, beefTx := range a.Beef.Txs {
+		txID := beefTx.Tx.TxID()
+		if _, ok := related[txID]; !ok {
+			return errors.Wrapf(ErrAtomicBeefUnrelatedTx, "tx %s", txID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package spv_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc/spv"
+)
+
+// brc95Hex is the BRC-62 example wrapped as an Atomic BEEF about its payment tx.
+const brc95Hex = "010101011c0dd2079ff75f8c6f2c88d5363babbda10f544c5e73033212117de6ae287415" + brc62Hex
+
+func newUnrelatedTx(t *testing.T) *bt.Tx {
+	t.Helper()
+	tx := bt.NewTx()
+	require.NoError(t, tx.From(
+		"b7b0650a7c3a1bd4716369783876348b59f5404784970192cec1996e86950576", 0,
+		"76a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac", 1000,
+	))
+	require.NoError(t, tx.PayToAddress("1NRoySJ9Lvby6DuE2UQYnyT67AASwNZxGb", 900))
+	return tx
+}
+
+func TestNewAtomicBeef(t *testing.T) {
+	beef, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+
+	atomic, err := spv.NewAtomicBeef(beef)
+	require.NoError(t, err)
+	require.Equal(t, beef.Txs[1].Tx.TxID(), atomic.SubjectTxID)
+	require.Equal(t, beef.Txs[1].Tx, atomic.SubjectTx())
+
+	str, err := atomic.String()
+	require.NoError(t, err)
+
+	require.Equal(t, brc95Hex, str)
+
+	parsed, err := spv.NewAtomicBeefFromStr(str)
+	require.NoError(t, err)
+	require.Equal(t, atomic.SubjectTxID, parsed.SubjectTxID)
+	require.NoError(t, parsed.Validate())
+
+	reencoded, err := parsed.String()
+	require.NoError(t, err)
+	require.Equal(t, str, reencoded)
+
+	_, err = spv.NewAtomicBeef(&spv.Beef{})
+	require.ErrorIs(t, err, spv.ErrAtomicBeefSubjectMissing)
+}
+
+func TestNewAtomicBeefFromStrInvalid(t *testing.T) {
+	tests := map[string]struct {
+		hex    string
+		expErr error
+	}{
+		"too short": {
+			hex:    "01010101",
+			expErr: spv.ErrTruncatedBeef,
+		},
+		"plain beef is not atomic": {
+			hex:    brc62Hex,
+			expErr: spv.ErrNotAtomicBeef,
+		},
+		"invalid beef after the subject": {
+			hex:    brc95Hex[:len(brc95Hex)-2],
+			expErr: spv.ErrTruncatedBeef,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := spv.NewAtomicBeefFromStr(test.hex)
+			require.Error(t, err)
+			require.ErrorIs(t, errors.Cause(err), test.expErr)
+		})
+	}
+}
+
+func TestVerifier_VerifyAtomicBeef(t *testing.T) {
+	beef, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+	subjectTxID := beef.Txs[1].Tx.TxID()
+
+	tests := map[string]struct {
+		atomic *spv.AtomicBeef
+		expErr error
+	}{
+		"valid atomic beef passes": {
+			atomic: &spv.AtomicBeef{SubjectTxID: subjectTxID, Beef: beef},
+		},
+		"subject not in beef fails": {
+			atomic: &spv.AtomicBeef{SubjectTxID: newUnrelatedTx(t).TxID(), Beef: beef},
+			expErr: spv.ErrAtomicBeefSubjectMissing,
+		},
+		"subject which is an ancestor only fails": {
+			atomic: &spv.AtomicBeef{SubjectTxID: brc62AnchoredTxID, Beef: beef},
+			expErr: spv.ErrAtomicBeefUnrelatedTx,
+		},
+		"unrelated tx in beef fails": {
+			atomic: &spv.AtomicBeef{
+				SubjectTxID: subjectTxID,
+				Beef: &spv.Beef{
+					BUMPs: beef.BUMPs,
+					Txs: []*spv.BeefTx{
+						beef.Txs[0],
+						{Tx: newUnrelatedTx(t)},
+						beef.Txs[1],
+					},
+				},
+			},
+			expErr: spv.ErrAtomicBeefUnrelatedTx,
+		},
+	}
+
+	v, err := spv.NewPaymentVerifier(&mockRootHeightClient{
+		roots: map[uint64]string{brc62Height: brc62Root},
+	})
+	require.NoError(t, err)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := v.VerifyAtomicBeef(context.Background(), test.atomic)
+			if test.expErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.ErrorIs(t, errors.Cause(err), test.expErr)
+		})
+	}
+}
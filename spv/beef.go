@@ -0,0 +1,282 @@
+package spv
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/pkg/errors"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// BeefVersion is the version marker which prefixes every BEEF, written as
+// little endian bytes 0100beef.
+const BeefVersion = uint32(0xEFBE0001)
+
+/*
+| Field        | Purpose                                                          | Size (Bytes) |
+|--------------|------------------------------------------------------------------|--------------|
+| version      | BeefVersion as uint32 little endian                              | 4            |
+| nBUMPs       | Number of BUMPs which follow                                     | VarInt       |
+| bumps        | Every BUMP in BRC-74 binary format, one after another            | -            |
+| nTxs         | Number of transactions which follow                              | VarInt       |
+| txs          | Every tx in raw format, each followed by a hasBUMP byte and, if  | -            |
+|              | hasBUMP is 1, the VarInt index of its BUMP                       |              |
+*/
+
+// Beef is a set of transactions along with the BUMPs which anchor the confirmed ones,
+// encoded according to BRC-62 https://brc.dev/62.
+//
+// Transactions are ordered so that parents always come before the transactions spending
+// them, which leaves the payment transaction last.
+type Beef struct {
+	BUMPs []*bc.BUMP
+	Txs   []*BeefTx
+}
+
+// BeefTx is a transaction within a Beef. If HasBUMP is set, BUMPIndex is the index
+// into Beef.BUMPs of the proof anchoring the transaction in a block.
+type BeefTx struct {
+	Tx        *bt.Tx
+	HasBUMP   bool
+	BUMPIndex uint64
+}
+
+// NewBeefFromBytes parses a BRC-62 BEEF byte slice into the Beef structure, returning
+// ErrLimitExceeded if it exceeds any Limits set by opts.
+func NewBeefFromBytes(b []byte, opts ...ParseOpt) (*Beef, error) {
+	l := newLimits(opts...)
+	if len(b) < 4 {
+		return nil, ErrTruncatedBeef
+	}
+	if err := l.checkBytes(b); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(b[:4]) != BeefVersion {
+		return nil, ErrUnsupportedBeefVersion
+	}
+	offset := 4
+
+	nBUMPs, size, ok := bc.ReadVarInt(b, offset)
+	if !ok {
+		return nil, ErrTruncatedBeef
+	}
+	offset += size
+
+	beef := &Beef{}
+	for i := uint64(0); i < nBUMPs; i++ {
+		if offset >= len(b) {
+			return nil, ErrTruncatedBeef
+		}
+		bump, size, err := bc.NewBUMPFromStream(b[offset:], l.bumpOpts()...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse bump %d", i)
+		}
+		offset += size
+		beef.BUMPs = append(beef.BUMPs, bump)
+	}
+
+	nTxs, size, ok := bc.ReadVarInt(b, offset)
+	if !ok {
+		return nil, ErrTruncatedBeef
+	}
+	offset += size
+	if nTxs > uint64(len(b)-offset) {
+		return nil, ErrTruncatedBeef
+	}
+	if err := l.checkTxs(int(nTxs)); err != nil { //nolint:gosec // G115: Safe conversion - nTxs is bounded by len(b)
+		return nil, err
+	}
+
+	for i := uint64(0); i < nTxs; i++ {
+		if offset >= len(b) {
+			return nil, ErrTruncatedBeef
+		}
+		tx, size, err := bt.NewTxFromStream(b[offset:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse tx %d", i)
+		}
+		offset += size
+
+		if offset >= len(b) {
+			return nil, ErrTruncatedBeef
+		}
+		if b[offset] > 1 {
+			return nil, errors.Wrapf(ErrInvalidBeefHasBUMP, "tx %s has %#02x", tx.TxID(), b[offset])
+		}
+		beefTx := &BeefTx{Tx: tx, HasBUMP: b[offset] == 1}
+		offset++
+		if beefTx.HasBUMP {
+			beefTx.BUMPIndex, size, ok = bc.ReadVarInt(b, offset)
+			if !ok {
+				return nil, ErrTruncatedBeef
+			}
+			offset += size
+			if beefTx.BUMPIndex >= uint64(len(beef.BUMPs)) {
+				return nil, errors.Wrapf(ErrBeefBUMPIndexOutOfRange, "tx %s", tx.TxID())
+			}
+		}
+		beef.Txs = append(beef.Txs, beefTx)
+	}
+
+	if offset != len(b) {
+		return nil, ErrBeefTrailingData
+	}
+
+	return beef, nil
+}
+
+// NewBeefFromStr parses a BRC-62 BEEF hex string into the Beef structure.
+func NewBeefFromStr(str string, opts ...ParseOpt) (*Beef, error) {
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+	return NewBeefFromBytes(b, opts...)
+}
+
+// NewBeefFromAncestryJSON builds a Beef from an ancestry tree, using the supplied BUMPs
+// to anchor the transactions which carry a proof. Walking stops at any transaction
+// found in one of the BUMPs, in the same way as AncestryJSON.Bytes stops at a proof.
+//
+// Only the BUMPs which are needed are included in the Beef. An error is returned if a
+// transaction has a proof but none of the BUMPs contain its txid.
+func NewBeefFromAncestryJSON(a *AncestryJSON, bumps []*bc.BUMP) (*Beef, error) {
+	beef := &Beef{}
+	bumpIndexes := make(map[*bc.BUMP]uint64)
+	seen := make(map[string]struct{})
+
+	var walk func(e *AncestryJSON) error
+	walk = func(e *AncestryJSON) error {
+		tx, err := bt.NewTxFromString(e.RawTx)
+		if err != nil {
+			return err
+		}
+		txid := tx.TxID()
+		if _, ok := seen[txid]; ok {
+			return nil
+		}
+		seen[txid] = struct{}{}
+
+		beefTx := &BeefTx{Tx: tx}
+		if bump := findBUMP(bumps, txid); bump != nil {
+			idx, ok := bumpIndexes[bump]
+			if !ok {
+				idx = uint64(len(beef.BUMPs))
+				bumpIndexes[bump] = idx
+				beef.BUMPs = append(beef.BUMPs, bump)
+			}
+			beefTx.HasBUMP = true
+			beefTx.BUMPIndex = idx
+		} else {
+			if e.IsAnchored() {
+				return errors.Wrapf(ErrBeefMissingBUMP, "tx %s", txid)
+			}
+			// parents are written before the tx spending them.
+			for _, input := range tx.Inputs {
+				parent, ok := e.Parents[input.PreviousTxIDStr()]
+				if !ok {
+					continue
+				}
+				if err := walk(parent); err != nil {
+					return err
+				}
+			}
+		}
+		beef.Txs = append(beef.Txs, beefTx)
+		return nil
+	}
+
+	if err := walk(a); err != nil {
+		return nil, err
+	}
+
+	return beef, nil
+}
+
+// findBUMP returns the first BUMP flagging txid as one of its txids, or nil.
+func findBUMP(bumps []*bc.BUMP, txid string) *bc.BUMP {
+	for _, bump := range bumps {
+		for _, id := range bump.Txids() {
+			if id == txid {
+				return bump
+			}
+		}
+	}
+	return nil
+}
+
+// Bytes encodes the Beef in BRC-62 binary format.
+func (b *Beef) Bytes() ([]byte, error) {
+	bytes := make([]byte, 4, 4+len(b.Txs)*250)
+	binary.LittleEndian.PutUint32(bytes, BeefVersion)
+
+	bytes = append(bytes, bt.VarInt(uint64(len(b.BUMPs))).Bytes()...)
+	for _, bump := range b.BUMPs {
+		bumpBytes, err := bump.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		bytes = append(bytes, bumpBytes...)
+	}
+
+	bytes = append(bytes, bt.VarInt(uint64(len(b.Txs))).Bytes()...)
+	for _, beefTx := range b.Txs {
+		bytes = append(bytes, beefTx.Tx.Bytes()...)
+		if !beefTx.HasBUMP {
+			bytes = append(bytes, 0)
+			continue
+		}
+		if beefTx.BUMPIndex >= uint64(len(b.BUMPs)) {
+			return nil, errors.Wrapf(ErrBeefBUMPIndexOutOfRange, "tx %s", beefTx.Tx.TxID())
+		}
+		bytes = append(bytes, 1)
+		bytes = append(bytes, bt.VarInt(beefTx.BUMPIndex).Bytes()...)
+	}
+
+	return bytes, nil
+}
+
+// String encodes the Beef as a hex string.
+func (b *Beef) String() (string, error) {
+	bytes, err := b.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// ancestry converts the Beef into the map used during payment verification,
+// keyed in the same way as the output of parseAncestry, and enforces the Limits l.
+func (b *Beef) ancestry(l *Limits) (map[[32]byte]*ancestry, error) {
+	for _, bump := range b.BUMPs {
+		if err := l.checkBUMP(bump); err != nil {
+			return nil, err
+		}
+	}
+	aa := make(map[[32]byte]*ancestry, len(b.Txs))
+	depths := l.newDepthTracker()
+	for _, beefTx := range b.Txs {
+		if len(beefTx.Tx.Inputs) == 0 {
+			return nil, ErrNoTxInputsToVerify
+		}
+		a := &ancestry{Tx: beefTx.Tx}
+		if beefTx.HasBUMP {
+			if beefTx.BUMPIndex >= uint64(len(b.BUMPs)) {
+				return nil, errors.Wrapf(ErrBeefBUMPIndexOutOfRange, "tx %s", beefTx.Tx.TxID())
+			}
+			a.BUMP = b.BUMPs[beefTx.BUMPIndex]
+		}
+		var txID [32]byte
+		copy(txID[:], beefTx.Tx.TxIDBytes())
+		aa[txID] = a
+		if err := l.checkTxs(len(aa)); err != nil {
+			return nil, err
+		}
+		if err := depths.add(txID, beefTx.Tx); err != nil {
+			return nil, err
+		}
+	}
+	return aa, nil
+}
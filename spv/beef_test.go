@@ -0,0 +1,264 @@
+package spv_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+	"github.com/bsv-blockchain/go-bc/spv"
+)
+
+const (
+	// brc62Hex is the example BEEF given in BRC-62.
+	brc62Hex          = "0100beef01fe636d0c0007021400fe507c0c7aa754cef1f7889d5fd395cf1f785dd7de98eed895dbedfe4e5bc70d1502ac4e164f5bc16746bb0868404292ac8318bbac3800e4aad13a014da427adce3e010b00bc4ff395efd11719b277694cface5aa50d085a0bb81f613f70313acd28cf4557010400574b2d9142b8d28b61d88e3b2c3f44d858411356b49a28a4643b6d1a6a092a5201030051a05fc84d531b5d250c23f4f886f6812f9fe3f402d61607f977b4ecd2701c19010000fd781529d58fc2523cf396a7f25440b409857e7e221766c57214b1d38c7b481f01010062f542f45ea3660f86c013ced80534cb5fd4c19d66c56e7e8c5d4bf2d40acc5e010100b121e91836fd7cd5102b654e9f72f3cf6fdbfd0b161c53a9c54b12c841126331020100000001cd4e4cac3c7b56920d1e7655e7e260d31f29d9a388d04910f1bbd72304a79029010000006b483045022100e75279a205a547c445719420aa3138bf14743e3f42618e5f86a19bde14bb95f7022064777d34776b05d816daf1699493fcdf2ef5a5ab1ad710d9c97bfb5b8f7cef3641210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013e660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac0000000001000100000001ac4e164f5bc16746bb0868404292ac8318bbac3800e4aad13a014da427adce3e000000006a47304402203a61a2e931612b4bda08d541cfb980885173b8dcf64a3471238ae7abcd368d6402204cbf24f04b9aa2256d8901f0ed97866603d2be8324c2bfb7a37bf8fc90edd5b441210263e2dee22b1ddc5e11f6fab8bcd2378bdd19580d640501ea956ec0e786f93e76ffffffff013c660000000000001976a9146bfd5c7fbe21529d45803dbcf0c87dd3c71efbc288ac0000000000"
+	brc62Root         = "bb6f640cc4ee56bf38eb5a1969ac0c16caa2d3d202b22bf3735d10eec0ca6e00"
+	brc62Height       = 814435
+	brc62AnchoredTxID = "3ecead27a44d013ad1aae40038acbb1883ac9242406808bb4667c15b4f164eac"
+)
+
+type mockRootHeightClient struct {
+	mockBlockHeaderClient
+	roots map[uint64]string
+}
+
+// IsValidRootForHeight is a mock implementation of spv.MerkleRootHeightVerifier.
+func (m *mockRootHeightClient) IsValidRootForHeight(_ context.Context, root string, height uint64) (bool, error) {
+	return m.roots[height] == root, nil
+}
+
+func TestNewBeefFromStr(t *testing.T) {
+	beef, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+	require.Len(t, beef.BUMPs, 1)
+	require.Len(t, beef.Txs, 2)
+
+	require.Equal(t, brc62AnchoredTxID, beef.Txs[0].Tx.TxID())
+	require.True(t, beef.Txs[0].HasBUMP)
+	require.Equal(t, uint64(0), beef.Txs[0].BUMPIndex)
+	require.False(t, beef.Txs[1].HasBUMP)
+
+	str, err := beef.String()
+	require.NoError(t, err)
+	require.Equal(t, brc62Hex, str)
+}
+
+func TestNewBeefFromBytesInvalid(t *testing.T) {
+	valid, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+	b, err := valid.Bytes()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		b      []byte
+		opts   []spv.ParseOpt
+		expErr error
+	}{
+		"empty bytes": {
+			b:      []byte{},
+			expErr: spv.ErrTruncatedBeef,
+		},
+		"wrong version": {
+			b:      []byte{0x01, 0x00, 0x00, 0x00, 0x00},
+			expErr: spv.ErrUnsupportedBeefVersion,
+		},
+		"version only": {
+			b:      b[:4],
+			expErr: spv.ErrTruncatedBeef,
+		},
+		"missing final hasBUMP byte": {
+			b:      b[:len(b)-1],
+			expErr: spv.ErrTruncatedBeef,
+		},
+		"trailing data": {
+			b:      append(append([]byte{}, b...), 0x00),
+			expErr: spv.ErrBeefTrailingData,
+		},
+		"has bump flag other than 0 or 1": {
+			b: func() []byte {
+				bb := append([]byte{}, b...)
+				bb[len(bb)-1] = 2
+				return bb
+			}(),
+			expErr: spv.ErrInvalidBeefHasBUMP,
+		},
+		"bump index out of range": {
+			b: func() []byte {
+				bb, err := (&spv.Beef{
+					Txs: []*spv.BeefTx{{Tx: valid.Txs[1].Tx}},
+				}).Bytes()
+				require.NoError(t, err)
+				bb[len(bb)-1] = 1
+				return append(bb, 0)
+			}(),
+			expErr: spv.ErrBeefBUMPIndexOutOfRange,
+		},
+		"more bytes than limit": {
+			b:      b,
+			opts:   []spv.ParseOpt{spv.LimitBytes(len(b) - 1)},
+			expErr: spv.ErrLimitExceeded,
+		},
+		"more txs than limit": {
+			b:      b,
+			opts:   []spv.ParseOpt{spv.LimitTxs(1)},
+			expErr: spv.ErrLimitExceeded,
+		},
+		"more bump leaves than limit": {
+			b:      b,
+			opts:   []spv.ParseOpt{spv.LimitProofNodes(3)},
+			expErr: spv.ErrLimitExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := spv.NewBeefFromBytes(test.b, test.opts...)
+			require.Error(t, err)
+			require.ErrorIs(t, errors.Cause(err), test.expErr)
+		})
+	}
+}
+
+func TestNewBeefFromAncestryJSON(t *testing.T) {
+	beef, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+	parent := beef.Txs[0].Tx
+	payment := beef.Txs[1].Tx
+
+	ancestry := &spv.AncestryJSON{
+		TxID:  payment.TxID(),
+		RawTx: payment.String(),
+		Parents: map[string]*spv.AncestryJSON{
+			parent.TxID(): {
+				TxID:  parent.TxID(),
+				RawTx: parent.String(),
+				Proof: &bc.MerkleProof{TxOrID: parent.TxID()},
+			},
+		},
+	}
+
+	t.Run("builds the same beef as the spec", func(t *testing.T) {
+		built, err := spv.NewBeefFromAncestryJSON(ancestry, []*bc.BUMP{beef.BUMPs[0]})
+		require.NoError(t, err)
+		str, err := built.String()
+		require.NoError(t, err)
+		require.Equal(t, brc62Hex, str)
+	})
+
+	t.Run("unused bumps are dropped", func(t *testing.T) {
+		unrelated, err := bc.NewBUMPFromStr("0101010102912f77eefdd311e24f96850ed8e701381fc4943327f9cf73f9c4dec0d93a056d")
+		require.NoError(t, err)
+		built, err := spv.NewBeefFromAncestryJSON(ancestry, []*bc.BUMP{unrelated, beef.BUMPs[0]})
+		require.NoError(t, err)
+		require.Len(t, built.BUMPs, 1)
+		require.Equal(t, beef.BUMPs[0], built.BUMPs[0])
+	})
+
+	t.Run("anchored tx without a bump errors", func(t *testing.T) {
+		_, err := spv.NewBeefFromAncestryJSON(ancestry, nil)
+		require.Error(t, err)
+		require.ErrorIs(t, errors.Cause(err), spv.ErrBeefMissingBUMP)
+	})
+}
+
+// brc62HeaderChain returns a regtest header chain whose header at brc62Height has the
+// merkle root of the BRC-62 example BUMP.
+func brc62HeaderChain(t *testing.T) *bc.MemoryBlockHeaderChain {
+	t.Helper()
+	checkpoint, err := bc.NewBlockHeaderFromStr("0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4adae5494dffff7f2002000000")
+	require.NoError(t, err)
+	c, err := bc.NewMemoryBlockHeaderChain(checkpoint, brc62Height-1)
+	require.NoError(t, err)
+
+	prevHash, err := hex.DecodeString(bc.ReverseHexString(hex.EncodeToString(bc.Sha256Sha256(checkpoint.Bytes()))))
+	require.NoError(t, err)
+	root, err := hex.DecodeString(brc62Root)
+	require.NoError(t, err)
+	bh := &bc.BlockHeader{
+		Version:        0x20000000,
+		Time:           checkpoint.Time + 600,
+		HashPrevBlock:  prevHash,
+		HashMerkleRoot: root,
+		Bits:           checkpoint.Bits,
+	}
+	for !bh.Valid() {
+		bh.Nonce++
+	}
+	require.NoError(t, c.AddHeader(bh))
+	return c
+}
+
+func TestVerifyPayment_Beef(t *testing.T) {
+	beef, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+	paymentTx := beef.Txs[1].Tx
+
+	tests := map[string]struct {
+		bhc    bc.BlockHeaderChain
+		beef   *spv.Beef
+		opts   []spv.VerifyOpt
+		expErr error
+	}{
+		"valid beef passes": {
+			bhc:  &mockRootHeightClient{roots: map[uint64]string{brc62Height: brc62Root}},
+			beef: beef,
+		},
+		"valid beef against a header chain passes": {
+			bhc:  brc62HeaderChain(t),
+			beef: beef,
+		},
+		"valid beef against a go-sdk chain tracker passes": {
+			bhc:  bc.NewChainTrackerHeaderChain(bc.NewChainTracker(brc62HeaderChain(t))),
+			beef: beef,
+		},
+		"valid beef within limits passes": {
+			bhc:  &mockRootHeightClient{roots: map[uint64]string{brc62Height: brc62Root}},
+			beef: beef,
+			opts: []spv.VerifyOpt{spv.VerifyLimits(spv.LimitTxs(2), spv.LimitDepth(2), spv.LimitProofNodes(8))},
+		},
+		"beef deeper than limit fails": {
+			bhc:    &mockRootHeightClient{roots: map[uint64]string{brc62Height: brc62Root}},
+			beef:   beef,
+			opts:   []spv.VerifyOpt{spv.VerifyLimits(spv.LimitDepth(1))},
+			expErr: spv.ErrLimitExceeded,
+		},
+		"beef anchored in unknown block fails": {
+			bhc:    &mockRootHeightClient{roots: map[uint64]string{brc62Height + 1: brc62Root}},
+			beef:   beef,
+			expErr: spv.ErrInvalidProof,
+		},
+		"beef without the anchoring bump fails": {
+			bhc: &mockRootHeightClient{roots: map[uint64]string{brc62Height: brc62Root}},
+			beef: &spv.Beef{
+				Txs: []*spv.BeefTx{{Tx: beef.Txs[0].Tx}, {Tx: paymentTx}},
+			},
+			expErr: spv.ErrProofOrInputMissing,
+		},
+		"header chain which cannot verify by height fails": {
+			bhc:    &mockBlockHeaderClient{},
+			beef:   beef,
+			expErr: spv.ErrNoMerkleRootHeightVerifier,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, err := spv.NewPaymentVerifier(test.bhc)
+			require.NoError(t, err)
+
+			err = v.VerifyPayment(context.Background(), &spv.Payment{
+				PaymentTx: paymentTx,
+				Beef:      test.beef,
+			}, test.opts...)
+			if test.expErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.EqualError(t, errors.Cause(err), test.expErr.Error())
+		})
+	}
+}
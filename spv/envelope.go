@@ -2,7 +2,6 @@ package spv
 
 import (
 	"encoding/hex"
-	"log"
 	"sync"
 
 	"github.com/bsv-blockchain/go-bt/v2"
@@ -58,9 +57,8 @@ func (e *Envelope) CrunchyNutBytes() (*[]byte, error) {
 		},
 	}
 
-	err := serialiseCrunchyNutInputs(initialTx, &flake)
-	if err != nil {
-		log.Println(err)
+	if err := serialiseCrunchyNutInputs(initialTx, &flake); err != nil {
+		return nil, err
 	}
 	return &flake, nil
 }
@@ -70,7 +68,7 @@ func serialiseCrunchyNutInputs(parents map[string]*Envelope, flake *[]byte) erro
 	for _, input := range parents {
 		currentTx, err := hex.DecodeString(input.RawTx)
 		if err != nil {
-			log.Print(err)
+			return errors.Wrapf(err, "failed to decode tx %s", input.TxID)
 		}
 		dataLength := bt.VarInt(uint64(len(currentTx)))
 		*flake = append(*flake, flagTx)                // the first data will always be a rawTx.
@@ -104,34 +102,72 @@ func serialiseCrunchyNutInputs(parents map[string]*Envelope, flake *[]byte) erro
 	return nil
 }
 
-// NewCrunchyNutEnvelopeFromBytes will encode a spv envelope byte slice into the Envelope structure.
-func NewCrunchyNutEnvelopeFromBytes(b []byte) (*Envelope, error) {
+// NewCrunchyNutEnvelopeFromBytes will encode a spv envelope byte slice into the Envelope structure,
+// returning ErrLimitExceeded if it exceeds any Limits set by opts.
+func NewCrunchyNutEnvelopeFromBytes(b []byte, opts ...ParseOpt) (*Envelope, error) {
 	var envelope Envelope
-	var offset uint64
+	l := newLimits(opts...)
+	if len(b) == 0 {
+		return nil, ErrTruncatedAncestry
+	}
+	if err := l.checkBytes(b); err != nil {
+		return nil, err
+	}
 
 	// the first byte is the version number.
-	version := b[offset]
+	version := b[0]
 	if version != 1 {
 		return nil, errors.New("We can only handle version 1 of the SPV Envelope Binary format")
 	}
-	offset++
-	parseCrunchyNutFlakesRecursively(b, &offset, &envelope)
+	p := &crunchyNutParser{b: b, offset: 1, limits: l}
+	if err := p.parseFlakesRecursively(&envelope, 1); err != nil {
+		return nil, err
+	}
 	return &envelope, nil
 }
 
-// parseCrunchyNutChunksRecursively will identify the next chunk of data's type and length,
-// and pull out the stream into the appropriate struct.
-func parseCrunchyNutFlakesRecursively(b []byte, offset *uint64, eCurrent *Envelope) {
-	typeOfNextData := b[*offset]
-	*offset++
-	l, size := bt.NewVarIntFromBytes(b[*offset:])
-	// Safe conversion: size from VarInt is bounded by max varint size (9 bytes)
-	*offset += uint64(size) //nolint:gosec // G115: Safe conversion - size is bounded by VarInt max size (9 bytes)
-	switch typeOfNextData {
+// crunchyNutParser holds the position within, and the resources used by, a CrunchyNut envelope being parsed.
+type crunchyNutParser struct {
+	b      []byte
+	offset int
+	limits *Limits
+	txs    int
+	mapi   int
+}
+
+// parseFlakesRecursively will identify the next chunk of data's type and length,
+// and pull out the stream into the appropriate struct, which is depth generations
+// from the first tx.
+func (p *crunchyNutParser) parseFlakesRecursively(eCurrent *Envelope, depth int) error {
+	for {
+		if err := p.parseFlake(eCurrent, depth); err != nil {
+			return err
+		}
+		if len(p.b) <= p.offset {
+			return nil
+		}
+	}
+}
+
+// parseFlake parses the next chunk into eCurrent, recursing into the parents of a tx.
+func (p *crunchyNutParser) parseFlake(eCurrent *Envelope, depth int) error {
+	chunk, size, err := parseChunk(p.b, p.offset)
+	if err != nil {
+		return err
+	}
+	p.offset += size
+	switch chunk.ContentType {
 	case flagTx:
-		tx, err := bt.NewTxFromBytes(b[*offset : *offset+uint64(l)])
+		p.txs++
+		if err := p.limits.checkTxs(p.txs); err != nil {
+			return err
+		}
+		if err := p.limits.checkDepth(depth); err != nil {
+			return err
+		}
+		tx, err := bt.NewTxFromBytes(chunk.Data)
 		if err != nil {
-			log.Println(err)
+			return err
 		}
 		txid := tx.TxID()
 		inputs := map[string]*Envelope{}
@@ -140,64 +176,47 @@ func parseCrunchyNutFlakesRecursively(b []byte, offset *uint64, eCurrent *Envelo
 		}
 		eCurrent.TxID = txid
 		eCurrent.RawTx = tx.String()
-		*offset += uint64(l)
-		if uint64(len(b)) > *offset && b[*offset] != flagTx {
-			parseCrunchyNutFlakesRecursively(b, offset, eCurrent)
+		if len(p.b) > p.offset && p.b[p.offset] != flagTx {
+			if err := p.parseFlakesRecursively(eCurrent, depth); err != nil {
+				return err
+			}
 		} else {
 			eCurrent.Parents = inputs
 		}
 		for _, input := range inputs {
-			if uint64(len(b)) > *offset {
-				parseCrunchyNutFlakesRecursively(b, offset, input)
+			if len(p.b) > p.offset {
+				if err := p.parseFlakesRecursively(input, depth+1); err != nil {
+					return err
+				}
 			}
 		}
 	case flagProof:
-		binaryProof, err := parseBinaryMerkleProof(b[*offset : *offset+uint64(l)])
+		proof, err := bc.NewMerkleProofFromBytes(chunk.Data)
 		if err != nil {
-			log.Println(err)
-		}
-		proof := bc.MerkleProof{
-			Index:      binaryProof.index,
-			TxOrID:     binaryProof.txOrID,
-			Target:     binaryProof.target,
-			Nodes:      binaryProof.nodes,
-			TargetType: flagType(binaryProof.flags),
-			// ignoring proofType and compositeType for this version.
-		}
-		eCurrent.Proof = &proof
-		*offset += uint64(l)
+			return err
+		}
+		if err := p.limits.checkProofNodes(len(proof.Nodes)); err != nil {
+			return err
+		}
+		eCurrent.Proof = proof
 	case flagMapi:
-		mapiResponse, err := bc.NewMapiCallbackFromBytes(b[*offset : *offset+uint64(l)])
+		p.mapi++
+		if err := p.limits.checkMapiCallbacks(p.mapi); err != nil {
+			return err
+		}
+		mapiResponse, err := bc.NewMapiCallbackFromBytes(chunk.Data)
 		if err != nil {
-			log.Println(err)
+			return err
 		}
 		if eCurrent.MapiResponses != nil {
 			eCurrent.MapiResponses = append(eCurrent.MapiResponses, *mapiResponse)
 		} else {
 			eCurrent.MapiResponses = []bc.MapiCallback{*mapiResponse}
 		}
-		*offset += uint64(l)
-	default:
-		log.Printf("Unknown data type: %v, used for: %v", typeOfNextData, b[*offset:*offset+uint64(l)])
-		*offset += uint64(l)
-	}
-	if uint64(len(b)) > *offset {
-		parseCrunchyNutFlakesRecursively(b, offset, eCurrent)
-	}
-}
-
-func flagType(flags byte) string {
-	switch flags & targetTypeFlags {
-	// if bits 1 and 2 of flags are NOT set, target should contain a block hash (32 bytes).
-	// if bit 2 of flags is set, the target should contain a merkle root (32 bytes).
-	case 0, 4:
-		return "blockhash"
-	// if bit 1 of flags is set, the target should contain a block header (80 bytes).
-	case 2:
-		return h
 	default:
-		return ""
+		return errors.Wrapf(ErrUnknownChunkType, "type %d at offset %d", chunk.ContentType, p.offset-size)
 	}
+	return nil
 }
 
 // SpecialKBytes takes a spvEnvelope struct and returns a pointer to the serialized bytes.
@@ -217,9 +236,8 @@ func (e *Envelope) SpecialKBytes() (*[]byte, error) {
 		},
 	}
 
-	err := serialiseSpecialKInputs(initialTx, &flake)
-	if err != nil {
-		log.Println(err)
+	if err := serialiseSpecialKInputs(initialTx, &flake); err != nil {
+		return nil, err
 	}
 	return &flake, nil
 }
@@ -229,7 +247,7 @@ func serialiseSpecialKInputs(parents map[string]*Envelope, flake *[]byte) error
 	for _, input := range parents {
 		currentTx, err := hex.DecodeString(input.RawTx)
 		if err != nil {
-			log.Print(err)
+			return errors.Wrapf(err, "failed to decode tx %s", input.TxID)
 		}
 		// the transaction itself
 		dataLength := bt.VarInt(uint64(len(currentTx)))
@@ -276,11 +294,25 @@ func serialiseSpecialKInputs(parents map[string]*Envelope, flake *[]byte) error
 	return nil
 }
 
-// NewSpecialKEnvelopeFromBytes will encode a spv envelope byte slice into the Envelope structure.
-func NewSpecialKEnvelopeFromBytes(b []byte) (*Envelope, error) {
-	allBinary := uint64(len(b))
+// isNullFlake reports whether a SpecialK flake is null, standing in for a missing tx, proof or
+// mapi responses.
+func isNullFlake(flake []byte) bool {
+	return len(flake) < 2
+}
+
+// NewSpecialKEnvelopeFromBytes will encode a spv envelope byte slice into the Envelope structure,
+// returning ErrLimitExceeded if it exceeds any Limits set by opts.
+func NewSpecialKEnvelopeFromBytes(b []byte, opts ...ParseOpt) (*Envelope, error) {
+	allBinary := len(b)
 	var envelope Envelope
-	var offset uint64
+	var offset int
+	l := newLimits(opts...)
+	if allBinary == 0 {
+		return nil, ErrTruncatedAncestry
+	}
+	if err := l.checkBytes(b); err != nil {
+		return nil, err
+	}
 
 	// the first byte is the version number.
 	version := b[offset]
@@ -291,11 +323,26 @@ func NewSpecialKEnvelopeFromBytes(b []byte) (*Envelope, error) {
 
 	// split up the binary into flakes where each one is to be processed concurrently.
 	var flakes [][]byte
+	var nTxs int
 	for ok := true; ok; ok = allBinary > offset {
-		l, size := bt.NewVarIntFromBytes(b[offset:])
-		offset += uint64(size) //nolint:gosec // G115: Safe conversion - size is bounded by VarInt max size (9 bytes)
-		flake := b[offset : offset+uint64(l)]
-		offset += uint64(l)
+		n, size, read := bc.ReadVarInt(b, offset)
+		if !read {
+			return nil, ErrTruncatedAncestry
+		}
+		offset += size
+		if n > uint64(allBinary-offset) {
+			return nil, errors.Wrapf(ErrOversizedVarInt, "flake length %d at offset %d", n, offset-size)
+		}
+		flake := b[offset : offset+int(n)] //nolint:gosec // G115: Safe conversion - n is bounded by len(b)
+		offset += int(n)                   //nolint:gosec // G115: Safe conversion - n is bounded by len(b)
+		// txs are counted as they're decoded below: the flake at idx holds a tx if idx%3 == 0,
+		// as every tx is followed by its proof and mapi flakes, and it isn't null.
+		if idx := len(flakes); idx%3 == 0 && !isNullFlake(flake) {
+			nTxs++
+			if err := l.checkTxs(nTxs); err != nil {
+				return nil, err
+			}
+		}
 		flakes = append(flakes, flake)
 	}
 
@@ -309,6 +356,16 @@ func NewSpecialKEnvelopeFromBytes(b []byte) (*Envelope, error) {
 	mapiCallbacks := make(map[string][]bc.MapiCallback)
 
 	wg := sync.WaitGroup{}
+	var mu sync.Mutex
+	var firstErr error
+	var nMapi int
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
 
 	// listen to these channels in perpetuity until we're done.
 	go func() {
@@ -323,7 +380,8 @@ func NewSpecialKEnvelopeFromBytes(b []byte) (*Envelope, error) {
 					if len(txid) > 64 {
 						tr, err := bt.NewTxFromString(txid)
 						if err != nil {
-							log.Println(err)
+							setErr(errors.Wrap(err, "failed to parse proof tx"))
+							continue
 						}
 						txid = tr.TxID()
 					}
@@ -342,7 +400,7 @@ func NewSpecialKEnvelopeFromBytes(b []byte) (*Envelope, error) {
 
 	for idx, flake := range flakes {
 		// filter out the null values
-		if len(flake) < 2 {
+		if isNullFlake(flake) {
 			continue
 		}
 		wg.Add(1)
@@ -352,19 +410,34 @@ func NewSpecialKEnvelopeFromBytes(b []byte) (*Envelope, error) {
 			case 2:
 				mcb, err := parseSpecialKMapi(flake)
 				if err != nil {
-					log.Println(err)
+					setErr(err)
+					return
+				}
+				mu.Lock()
+				nMapi += len(mcb)
+				err = l.checkMapiCallbacks(nMapi)
+				mu.Unlock()
+				if err != nil {
+					setErr(err)
+					return
 				}
 				mapiCallbackChan <- mcb
 			case 1:
 				proof, err := parseSpecialKProof(flake)
 				if err != nil {
-					log.Println(err)
+					setErr(err)
+					return
+				}
+				if err := l.checkProofNodes(len(proof.Nodes)); err != nil {
+					setErr(err)
+					return
 				}
 				proofChan <- proof
 			case 0:
 				tx, err := parseSpecialKFlakeTx(flake)
 				if err != nil {
-					log.Println(err)
+					setErr(err)
+					return
 				}
 				txid := tx.TxID()
 				if idx == 0 {
@@ -383,25 +456,41 @@ func NewSpecialKEnvelopeFromBytes(b []byte) (*Envelope, error) {
 
 	wg.Wait()
 	done <- true
+	if firstErr != nil {
+		return nil, firstErr
+	}
 
 	// construct something useful
 	// iterate through all the transactions, adding them to the struct's Parents
 	// if they're in the struct deleting them, if they're not then leave them, and go one input deep.
 	// then run through again until they're all done.
-	// // keep building struct unless all txs are in the struct.
-	for ok := true; ok; ok = len(txs) > 0 {
+	// keep building struct unless all txs are in the struct, or none of those left can be added.
+	for len(txs) > 0 {
+		var found bool
 		// iterate through txs
 		for txid, tx := range txs {
 			proof := proofs[txid]
 			mapiCallback := mapiCallbacks[txid]
-			_ = searchParents(&txs, &envelope, txid, tx, proof, mapiCallback)
+			ok, err := searchParents(&txs, &envelope, 1, l, txid, tx, proof, mapiCallback)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.Wrapf(ErrEnvelopeUnrelatedTx, "%d txs left", len(txs))
 		}
 	}
-
 	return &envelope, nil
 }
 
-func searchParents(txs *map[string]*bt.Tx, currentEnvelope *Envelope, txid string, tx *bt.Tx, p *bc.MerkleProof, m []bc.MapiCallback) bool {
+// searchParents adds tx to currentEnvelope, which is depth generations from the first tx, or
+// whichever of its parents spends it, returning ErrLimitExceeded if that is deeper than l allows.
+func searchParents(txs *map[string]*bt.Tx, currentEnvelope *Envelope, depth int, l *Limits, txid string, tx *bt.Tx,
+	p *bc.MerkleProof, m []bc.MapiCallback,
+) (bool, error) {
 	// is this the route transaction, and do we know it?
 	if txid == currentEnvelope.TxID {
 		currentEnvelope.RawTx = tx.String()
@@ -412,12 +501,15 @@ func searchParents(txs *map[string]*bt.Tx, currentEnvelope *Envelope, txid strin
 			currentEnvelope.Proof = p
 		}
 		delete(*txs, txid)
-		return true
+		return true, nil
 	}
 	// iterate through inputs
 	for k := range currentEnvelope.Parents {
 		// if we find the correct place, add the tx.
 		if k == txid {
+			if err := l.checkDepth(depth + 1); err != nil {
+				return false, err
+			}
 			var nextEnvelope Envelope
 			nextEnvelope.TxID = txid
 			nextEnvelope.RawTx = tx.String()
@@ -435,17 +527,17 @@ func searchParents(txs *map[string]*bt.Tx, currentEnvelope *Envelope, txid strin
 				currentEnvelope.Parents[txid] = &nextEnvelope
 			}
 			delete(*txs, txid)
-			return true
+			return true, nil
 		}
 	}
 	// if we didn't find it at this level, go one deeper into the struct to find a parent of a parent... etc.
-	if len(currentEnvelope.Parents) > 0 {
-		for _, parent := range currentEnvelope.Parents {
-			// this means the context of the next iteration through will be from the parent here, which is one step deeper.
-			return searchParents(txs, parent, txid, tx, p, m)
+	for _, parent := range currentEnvelope.Parents {
+		// this means the context of the next iteration through will be from the parent here, which is one step deeper.
+		if ok, err := searchParents(txs, parent, depth+1, l, txid, tx, p, m); ok || err != nil {
+			return ok, err
 		}
 	}
-	return false
+	return false, nil
 }
 
 // parseSpecialKFlakesRecursively will identify the next chunk of data's type and length,
@@ -454,12 +546,7 @@ func parseSpecialKFlakeTx(b []byte) (*bt.Tx, error) {
 	if len(b) == 0 {
 		return nil, errors.New("tx bytes have no length")
 	}
-	tx, err := bt.NewTxFromBytes(b)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	return tx, nil
+	return bt.NewTxFromBytes(b)
 }
 
 func parseSpecialKProof(b []byte) (*bc.MerkleProof, error) {
@@ -469,52 +556,21 @@ func parseSpecialKProof(b []byte) (*bc.MerkleProof, error) {
 	if b[0] == 0 && len(b) == 1 {
 		return nil, errors.New("proof number is 0")
 	}
-	binaryProof, err := parseBinaryMerkleProof(b)
+	proof, err := bc.NewMerkleProofFromBytes(b)
 	if err != nil {
-		log.Println(err)
-		return nil, errors.New("couldn't parse the proof bytes")
+		return nil, errors.Wrap(err, "couldn't parse the proof bytes")
 	}
-	proof := bc.MerkleProof{
-		Index:      binaryProof.index,
-		TxOrID:     binaryProof.txOrID,
-		Target:     binaryProof.target,
-		Nodes:      binaryProof.nodes,
-		TargetType: flagType(binaryProof.flags),
-		// ignoring proofType and compositeType for this version.
-	}
-	return &proof, nil
+	return proof, nil
 }
 
 func parseSpecialKMapi(b []byte) ([]bc.MapiCallback, error) {
-	if len(b) == 0 {
-		return nil, errors.New("There are no callback bytes")
-	}
-	var internalOffset uint64
-	allBinary := uint64(len(b))
-	numOfMapiResponses := b[internalOffset]
-	if numOfMapiResponses == 0 && len(b) == 1 {
-		return nil, errors.New("There are no callbacks")
-	}
-	internalOffset++
-
-	// split up the binary into flakes where each one is to be processed concurrently.
-	var responses [][]byte
-	for ok := true; ok; ok = allBinary > internalOffset {
-		l, size := bt.NewVarIntFromBytes(b[internalOffset:])
-		internalOffset += uint64(size) //nolint:gosec // G115: Safe conversion - size is bounded by VarInt max size (9 bytes)
-		response := b[internalOffset : internalOffset+uint64(l)]
-		internalOffset += uint64(l)
-		responses = append(responses, response)
+	callbacks, err := parseMapiCallbacks(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse the callback bytes")
 	}
-
-	mapiResponses := make([]bc.MapiCallback, 0)
-	for _, response := range responses {
-		mapiResponse, err := bc.NewMapiCallbackFromBytes(response)
-		if err != nil {
-			log.Println(err)
-			return nil, errors.New("couldn't parse the callback bytes")
-		}
-		mapiResponses = append(mapiResponses, *mapiResponse)
+	mapiResponses := make([]bc.MapiCallback, 0, len(callbacks))
+	for _, callback := range callbacks {
+		mapiResponses = append(mapiResponses, *callback)
 	}
 	return mapiResponses, nil
 }
@@ -0,0 +1,118 @@
+package spv
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// specialKFlake returns b prefixed with its length, as a flake of a SpecialK envelope.
+func specialKFlake(b []byte) []byte {
+	return append(bt.VarInt(uint64(len(b))).Bytes(), b...)
+}
+
+// specialKEnvelope returns the SpecialK envelope bytes holding flakes.
+func specialKEnvelope(flakes ...[]byte) []byte {
+	b := []byte{0x01}
+	for _, flake := range flakes {
+		b = append(b, specialKFlake(flake)...)
+	}
+	return b
+}
+
+func TestNewSpecialKEnvelopeFromBytes_Malformed(t *testing.T) {
+	_, rawTx := loadAncestryBytes(t, "valid.json")
+	_, unrelatedTx := loadAncestryBytes(t, "valid_deep.json")
+
+	// a proof of a full tx which isn't a valid tx.
+	proof := []byte{0x01, 0x00, 0x21}
+	proof = append(proof, bytes.Repeat([]byte{0xff}, 33)...)
+	proof = append(proof, make([]byte, 32)...)
+	proof = append(proof, 0x00)
+
+	tests := map[string]struct {
+		b      []byte
+		expErr error
+	}{
+		"empty input": {
+			b:      []byte{},
+			expErr: ErrTruncatedAncestry,
+		},
+		"flake length beyond remaining bytes": {
+			b:      []byte{0x01, 0x05, 0x01},
+			expErr: ErrOversizedVarInt,
+		},
+		"proof of an invalid tx": {
+			b:      specialKEnvelope(rawTx, proof, nil),
+			expErr: io.ErrUnexpectedEOF,
+		},
+		"mapi response longer than flake": {
+			b:      specialKEnvelope(rawTx, nil, []byte{0x01, 0x09, 0x00}),
+			expErr: ErrOversizedVarInt,
+		},
+		"unrelated tx": {
+			b:      specialKEnvelope(rawTx, nil, nil, unrelatedTx),
+			expErr: ErrEnvelopeUnrelatedTx,
+		},
+		"first tx missing": {
+			b:      specialKEnvelope(nil, nil, nil, rawTx),
+			expErr: ErrEnvelopeUnrelatedTx,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NotPanics(t, func() {
+				e, err := NewSpecialKEnvelopeFromBytes(test.b)
+				require.ErrorIs(t, err, test.expErr)
+				require.Nil(t, e)
+			})
+		})
+	}
+}
+
+func TestNewCrunchyNutEnvelopeFromBytes_Malformed(t *testing.T) {
+	_, rawTx := loadAncestryBytes(t, "valid.json")
+	tx := txChunk(rawTx)
+
+	tests := map[string]struct {
+		b      []byte
+		expErr error
+	}{
+		"empty input": {
+			b:      []byte{},
+			expErr: ErrTruncatedAncestry,
+		},
+		"chunk length beyond remaining bytes": {
+			b:      []byte{0x01, flagTx, 0x05, 0x01},
+			expErr: ErrOversizedVarInt,
+		},
+		"unknown chunk type": {
+			b:      append(append([]byte{0x01}, tx...), 0x07, 0x01, 0x00),
+			expErr: ErrUnknownChunkType,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NotPanics(t, func() {
+				e, err := NewCrunchyNutEnvelopeFromBytes(test.b)
+				require.ErrorIs(t, err, test.expErr)
+				require.Nil(t, e)
+			})
+		})
+	}
+}
+
+func TestEnvelopeBytes_InvalidTx(t *testing.T) {
+	e := &Envelope{TxID: "aa", RawTx: "zz"}
+
+	b, err := e.CrunchyNutBytes()
+	require.Error(t, err)
+	require.Nil(t, b)
+
+	b, err = e.SpecialKBytes()
+	require.Error(t, err)
+	require.Nil(t, b)
+}
@@ -1,6 +1,12 @@
 package spv
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/bsv-blockchain/go-bc"
+)
 
 var (
 	// ErrNoTxInputs returns if an ancestry is attempted to be created from a transaction that has no inputs.
@@ -60,7 +66,8 @@ var (
 	ErrUnsupporredVersion = errors.New("we only support version 1 of the Ancestor Binary format")
 
 	// ErrInvalidMerkleFlags returns if a merkle proof being verified uses something other than the one currently supported.
-	ErrInvalidMerkleFlags = errors.New("invalid flags used in merkle proof")
+	// It is the same error as bc.ErrInvalidMerkleFlags, so either can be checked for.
+	ErrInvalidMerkleFlags = bc.ErrInvalidMerkleFlags
 
 	// ErrMissingTxidInProof returns if there's a missing txid in the proof.
 	ErrMissingTxidInProof = errors.New("missing txid in proof")
@@ -83,10 +90,12 @@ var (
 	// ErrInvalidTargetType is returned when TargetType or target field is invalid.
 	ErrInvalidTargetType = errors.New("invalid TargetType or target field")
 
-	// ErrOnlyMerkleBranchSupported is returned when a non-merkle branch proof format is used.
-	ErrOnlyMerkleBranchSupported = errors.New("only merkle branch supported in this version")
+	// ErrOnlyMerkleBranchSupported is returned when a proof type other than a merkle branch or tree is used.
+	ErrOnlyMerkleBranchSupported = errors.New("only merkle branch and tree proofs are supported")
 
-	// ErrOnlySingleProofSupported is returned when multiple proofs are provided.
+	// ErrOnlySingleProofSupported was returned when a composite proof was provided.
+	//
+	// Deprecated: composite proofs are supported and this is no longer returned.
 	ErrOnlySingleProofSupported = errors.New("only single proof supported in this version")
 
 	// ErrTxidMissing is returned when txid is missing in proof.
@@ -105,8 +114,93 @@ var (
 	ErrInvalidTxOrIDLength = errors.New("invalid txOrId length - must be at least 64 chars (32 bytes)")
 
 	// ErrInvalidTxLength is returned when tx length is invalid.
-	ErrInvalidTxLength = errors.New("invalid tx length (should be greater than 32 bytes)")
+	// It is the same error as bc.ErrInvalidMerkleProofTxLength, so either can be checked for.
+	ErrInvalidTxLength = bc.ErrInvalidMerkleProofTxLength
 
 	// ErrInvalidNodeType is returned when node type value is invalid.
-	ErrInvalidNodeType = errors.New("invalid value in node type")
+	// It is the same error as bc.ErrInvalidMerkleProofNodeType, so either can be checked for.
+	ErrInvalidNodeType = bc.ErrInvalidMerkleProofNodeType
+
+	// ErrScriptVerificationFailed is returned when an input's unlocking script does not satisfy
+	// the locking script of the output it spends. The error is always wrapped in a *ScriptError.
+	ErrScriptVerificationFailed = errors.New("script verification failed")
+
+	// ErrUnsupportedBeefVersion is returned when BEEF bytes do not start with the BRC-62 version marker.
+	ErrUnsupportedBeefVersion = errors.New("unsupported beef version, expected 0100beef")
+
+	// ErrTruncatedBeef is returned when BEEF bytes end before all the data they describe.
+	ErrTruncatedBeef = errors.New("beef bytes are truncated")
+
+	// ErrBeefTrailingData is returned when BEEF bytes continue after the last transaction.
+	ErrBeefTrailingData = errors.New("unexpected data after the last beef transaction")
+
+	// ErrBeefBUMPIndexOutOfRange is returned when a BEEF transaction references a BUMP which isn't present.
+	ErrBeefBUMPIndexOutOfRange = errors.New("beef tx bump index is out of range")
+
+	// ErrInvalidBeefHasBUMP is returned when the byte flagging whether a BEEF transaction has a BUMP
+	// is neither 0 nor 1.
+	ErrInvalidBeefHasBUMP = errors.New("beef tx has bump flag must be 0 or 1")
+
+	// ErrBeefMissingBUMP is returned when building a BEEF from an ancestry with a proof that no BUMP covers.
+	ErrBeefMissingBUMP = errors.New("no bump supplied for anchored tx")
+
+	// ErrTruncatedAncestry is returned when ancestry bytes end part way through a chunk.
+	ErrTruncatedAncestry = errors.New("ancestry bytes are truncated")
+
+	// ErrOversizedVarInt is returned when a length in the ancestry bytes is larger than the bytes remaining.
+	ErrOversizedVarInt = errors.New("varint length exceeds the remaining bytes")
+
+	// ErrOrphanChunk is returned when a proof or mapi chunk in the ancestry bytes precedes any tx.
+	ErrOrphanChunk = errors.New("ancestry chunk precedes any tx")
+
+	// ErrUnknownChunkType is returned when a chunk in the ancestry bytes has a type other than tx, proof or mapi.
+	ErrUnknownChunkType = errors.New("unknown ancestry chunk type")
+
+	// ErrEnvelopeUnrelatedTx is returned when envelope bytes contain a tx which is not an ancestor of
+	// the first tx in them.
+	ErrEnvelopeUnrelatedTx = errors.New("envelope contains a tx unrelated to the first tx")
+
+	// ErrTruncatedMerkleProof is returned when binary merkle proof bytes end part way through a field.
+	// It is the same error as bc.ErrTruncatedMerkleProof, so either can be checked for.
+	ErrTruncatedMerkleProof = bc.ErrTruncatedMerkleProof
+
+	// ErrLimitExceeded is returned when untrusted input exceeds one of the Limits it is parsed under.
+	// It is the same error as bc.ErrLimitExceeded, so either can be checked for.
+	ErrLimitExceeded = bc.ErrLimitExceeded
+
+	// ErrNotAtomicBeef is returned when Atomic BEEF bytes do not start with the BRC-95 prefix.
+	ErrNotAtomicBeef = errors.New("bytes are not an atomic beef, expected 01010101 prefix")
+
+	// ErrAtomicBeefSubjectMissing is returned when an Atomic BEEF does not contain its subject transaction.
+	ErrAtomicBeefSubjectMissing = errors.New("atomic beef subject tx is missing")
+
+	// ErrAtomicBeefUnrelatedTx is returned when an Atomic BEEF contains a transaction which is not
+	// an ancestor of its subject.
+	ErrAtomicBeefUnrelatedTx = errors.New("atomic beef contains a tx unrelated to the subject")
+
+	// ErrNoMerkleRootHeightVerifier is returned when a BUMP needs verifying but the bc.BlockHeaderChain
+	// supplied does not implement MerkleRootHeightVerifier.
+	ErrNoMerkleRootHeightVerifier = errors.New("block header chain cannot verify merkle roots by height")
 )
+
+// ScriptError reports which transaction input failed script verification and why.
+type ScriptError struct {
+	TxID string
+	Vin  int
+	Err  error
+}
+
+// Error returns the failing tx and input along with the interpreter error.
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("%s: tx %s input %d: %v", ErrScriptVerificationFailed, e.TxID, e.Vin, e.Err)
+}
+
+// Unwrap returns the underlying interpreter error.
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows errors.Is(err, ErrScriptVerificationFailed) to match a *ScriptError.
+func (e *ScriptError) Is(target error) bool {
+	return target == ErrScriptVerificationFailed
+}
@@ -0,0 +1,98 @@
+package spv
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// headerCall is a single lookup against the wrapped header chain, shared by every
+// caller asking for the same key while it is in flight and after it completes.
+type headerCall struct {
+	done   chan struct{}
+	header *bc.BlockHeader
+	valid  bool
+	err    error
+}
+
+type rootHeight struct {
+	root   string
+	height uint64
+}
+
+// headerCache wraps a bc.BlockHeaderChain so that each block hash is only looked up
+// once, however many proofs being verified concurrently are anchored in that block.
+//
+// It is scoped to a single verification, so doesn't need evicting.
+type headerCache struct {
+	bhc     bc.BlockHeaderChain
+	mu      sync.Mutex
+	headers map[string]*headerCall
+	roots   map[rootHeight]*headerCall
+}
+
+// rootHeightCache is a headerCache over a header chain which is also a
+// MerkleRootHeightVerifier, deduplicating root lookups per root and height.
+type rootHeightCache struct {
+	*headerCache
+}
+
+// newHeaderCache returns bhc wrapped in a cache, which implements MerkleRootHeightVerifier
+// only if bhc does.
+func newHeaderCache(bhc bc.BlockHeaderChain) bc.BlockHeaderChain {
+	c := &headerCache{
+		bhc:     bhc,
+		headers: make(map[string]*headerCall),
+		roots:   make(map[rootHeight]*headerCall),
+	}
+	if _, ok := bhc.(MerkleRootHeightVerifier); ok {
+		return &rootHeightCache{headerCache: c}
+	}
+	return c
+}
+
+// BlockHeader returns the header from the wrapped chain, only asking for it the first time.
+func (c *headerCache) BlockHeader(ctx context.Context, blockHash string) (*bc.BlockHeader, error) {
+	c.mu.Lock()
+	call, ok := c.headers[blockHash]
+	if !ok {
+		call = &headerCall{done: make(chan struct{})}
+		c.headers[blockHash] = call
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		call.header, call.err = c.bhc.BlockHeader(ctx, blockHash)
+		close(call.done)
+	}
+	select {
+	case <-call.done:
+		return call.header, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// IsValidRootForHeight returns the result from the wrapped chain, only asking for it the first time.
+func (c *rootHeightCache) IsValidRootForHeight(ctx context.Context, merkleRoot string, height uint64) (bool, error) {
+	key := rootHeight{root: merkleRoot, height: height}
+	c.mu.Lock()
+	call, ok := c.roots[key]
+	if !ok {
+		call = &headerCall{done: make(chan struct{})}
+		c.roots[key] = call
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		call.valid, call.err = c.bhc.(MerkleRootHeightVerifier).IsValidRootForHeight(ctx, merkleRoot, height)
+		close(call.done)
+	}
+	select {
+	case <-call.done:
+		return call.valid, call.err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
@@ -0,0 +1,186 @@
+package spv
+
+import (
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/pkg/errors"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// Limits caps the resources spent parsing ancestry received from an untrusted source.
+// A zero value for any field means that resource is not limited.
+type Limits struct {
+	// MaxBytes is the most bytes an encoded ancestry may contain.
+	MaxBytes int
+	// MaxTxs is the most transactions an encoded ancestry may contain.
+	MaxTxs int
+	// MaxDepth is the most generations of transactions an ancestry may contain, a tx with
+	// none of its parents in the ancestry being the first generation.
+	MaxDepth int
+	// MaxProofNodes is the most nodes a single merkle proof, or leaves a single BUMP, may contain.
+	MaxProofNodes int
+	// MaxMapiCallbacks is the most mAPI callbacks an ancestry may contain in total.
+	MaxMapiCallbacks int
+}
+
+// ParseOpt defines a functional option used to set the Limits applied
+// when parsing ancestry.
+type ParseOpt func(l *Limits)
+
+// LimitBytes limits the size of an encoded ancestry to n bytes.
+func LimitBytes(n int) ParseOpt {
+	return func(l *Limits) {
+		l.MaxBytes = n
+	}
+}
+
+// LimitTxs limits the number of transactions in an ancestry to n.
+func LimitTxs(n int) ParseOpt {
+	return func(l *Limits) {
+		l.MaxTxs = n
+	}
+}
+
+// LimitDepth limits the number of generations of parents in an ancestry to n.
+func LimitDepth(n int) ParseOpt {
+	return func(l *Limits) {
+		l.MaxDepth = n
+	}
+}
+
+// LimitProofNodes limits the number of nodes in each merkle proof, and leaves in each BUMP, to n.
+func LimitProofNodes(n int) ParseOpt {
+	return func(l *Limits) {
+		l.MaxProofNodes = n
+	}
+}
+
+// LimitMapiCallbacks limits the total number of mAPI callbacks in an ancestry to n.
+func LimitMapiCallbacks(n int) ParseOpt {
+	return func(l *Limits) {
+		l.MaxMapiCallbacks = n
+	}
+}
+
+// newLimits returns the Limits set by opts.
+func newLimits(opts ...ParseOpt) *Limits {
+	l := &Limits{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// checkBytes returns ErrLimitExceeded if b is larger than MaxBytes.
+func (l *Limits) checkBytes(b []byte) error {
+	if l.MaxBytes > 0 && len(b) > l.MaxBytes {
+		return errors.Wrapf(ErrLimitExceeded, "%d bytes exceeds max of %d", len(b), l.MaxBytes)
+	}
+	return nil
+}
+
+// checkTxs returns ErrLimitExceeded if n is larger than MaxTxs.
+func (l *Limits) checkTxs(n int) error {
+	if l.MaxTxs > 0 && n > l.MaxTxs {
+		return errors.Wrapf(ErrLimitExceeded, "more than %d txs", l.MaxTxs)
+	}
+	return nil
+}
+
+// checkDepth returns ErrLimitExceeded if depth is larger than MaxDepth.
+func (l *Limits) checkDepth(depth int) error {
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return errors.Wrapf(ErrLimitExceeded, "ancestry deeper than %d", l.MaxDepth)
+	}
+	return nil
+}
+
+// checkProofNodes returns ErrLimitExceeded if n is larger than MaxProofNodes.
+func (l *Limits) checkProofNodes(n int) error {
+	if l.MaxProofNodes > 0 && n > l.MaxProofNodes {
+		return errors.Wrapf(ErrLimitExceeded, "%d proof nodes exceeds max of %d", n, l.MaxProofNodes)
+	}
+	return nil
+}
+
+// checkMapiCallbacks returns ErrLimitExceeded if n is larger than MaxMapiCallbacks.
+func (l *Limits) checkMapiCallbacks(n int) error {
+	if l.MaxMapiCallbacks > 0 && n > l.MaxMapiCallbacks {
+		return errors.Wrapf(ErrLimitExceeded, "more than %d mapi callbacks", l.MaxMapiCallbacks)
+	}
+	return nil
+}
+
+// bumpOpts returns the options limiting a BUMP parsed under these Limits.
+func (l *Limits) bumpOpts() []bc.BUMPOpt {
+	if l.MaxProofNodes <= 0 {
+		return nil
+	}
+	return []bc.BUMPOpt{bc.WithMaxBUMPLeaves(uint64(l.MaxProofNodes))}
+}
+
+// checkBUMP returns ErrLimitExceeded if the BUMP has more leaves than MaxProofNodes.
+func (l *Limits) checkBUMP(bump *bc.BUMP) error {
+	if l.MaxProofNodes <= 0 {
+		return nil
+	}
+	var n int
+	for _, level := range bump.Path {
+		n += len(level)
+	}
+	return l.checkProofNodes(n)
+}
+
+// depthTracker enforces MaxDepth as the txs of an ancestry are parsed, whatever order
+// they arrive in, failing as soon as any tx is deeper than allowed.
+type depthTracker struct {
+	limits *Limits
+	// depths holds the generation of each tx added.
+	depths map[[32]byte]int
+	// children holds the txs added which spend each txid.
+	children map[[32]byte][][32]byte
+}
+
+// newDepthTracker returns a depthTracker enforcing the MaxDepth of l.
+func (l *Limits) newDepthTracker() *depthTracker {
+	return &depthTracker{
+		limits:   l,
+		depths:   make(map[[32]byte]int),
+		children: make(map[[32]byte][][32]byte),
+	}
+}
+
+// add records tx, returning ErrLimitExceeded if it, or any tx already added which
+// descends from it, is now deeper than MaxDepth.
+func (d *depthTracker) add(txID [32]byte, tx *bt.Tx) error {
+	if d.limits.MaxDepth <= 0 {
+		return nil
+	}
+	depth := 1
+	for _, input := range tx.Inputs {
+		parentID := previousTxID(input)
+		d.children[parentID] = append(d.children[parentID], txID)
+		if pd, ok := d.depths[parentID]; ok && pd+1 > depth {
+			depth = pd + 1
+		}
+	}
+	return d.deepen(txID, depth)
+}
+
+// deepen sets the generation of txID to depth, if deeper than it was, and that of
+// its descendants to match.
+func (d *depthTracker) deepen(txID [32]byte, depth int) error {
+	if depth <= d.depths[txID] {
+		return nil
+	}
+	if err := d.limits.checkDepth(depth); err != nil {
+		return err
+	}
+	d.depths[txID] = depth
+	for _, child := range d.children[txID] {
+		if err := d.deepen(child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package spv
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAncestry_Limits(t *testing.T) {
+	valid, rawTx := loadAncestryBytes(t, "valid_deep.json")
+	aa, err := parseAncestry(valid, &Limits{})
+	require.NoError(t, err)
+
+	// a tx followed by a mapi chunk holding two empty callbacks.
+	mapi := append([]byte{0x01}, txChunk(rawTx)...)
+	mapi = append(mapi, flagMapi, 0x07, 0x02, 0x02, '{', '}', 0x02, '{', '}')
+
+	tests := map[string]struct {
+		b      []byte
+		opts   []ParseOpt
+		expErr error
+	}{
+		"no limits": {},
+		"limits at ancestry size": {
+			opts: []ParseOpt{
+				LimitBytes(len(valid)), LimitTxs(len(aa)), LimitDepth(4), LimitProofNodes(2), LimitMapiCallbacks(1),
+			},
+		},
+		"too many bytes": {
+			opts:   []ParseOpt{LimitBytes(len(valid) - 1)},
+			expErr: ErrLimitExceeded,
+		},
+		"too many txs": {
+			opts:   []ParseOpt{LimitTxs(len(aa) - 1)},
+			expErr: ErrLimitExceeded,
+		},
+		"too deep": {
+			opts:   []ParseOpt{LimitDepth(3)},
+			expErr: ErrLimitExceeded,
+		},
+		"too many proof nodes": {
+			opts:   []ParseOpt{LimitProofNodes(1)},
+			expErr: ErrLimitExceeded,
+		},
+		"mapi callbacks within limit": {
+			b:    mapi,
+			opts: []ParseOpt{LimitMapiCallbacks(2)},
+		},
+		"too many mapi callbacks": {
+			b:      mapi,
+			opts:   []ParseOpt{LimitMapiCallbacks(1)},
+			expErr: ErrLimitExceeded,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := test.b
+			if b == nil {
+				b = valid
+			}
+			_, err := parseAncestry(b, newLimits(test.opts...))
+			if test.expErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, test.expErr)
+		})
+	}
+}
+
+func TestEnvelopeFromBytes_Limits(t *testing.T) {
+	envelopes := tests
+	tests := map[string]struct {
+		envelope string
+		opts     []ParseOpt
+		expErr   error
+	}{
+		"large within limits": {
+			envelope: "large",
+			opts:     []ParseOpt{LimitTxs(10), LimitDepth(10), LimitProofNodes(3)},
+		},
+		"large too many txs": {
+			envelope: "large",
+			opts:     []ParseOpt{LimitTxs(1)},
+			expErr:   ErrLimitExceeded,
+		},
+		"large too deep": {
+			envelope: "large",
+			opts:     []ParseOpt{LimitDepth(1)},
+			expErr:   ErrLimitExceeded,
+		},
+		"large too many proof nodes": {
+			envelope: "large",
+			opts:     []ParseOpt{LimitProofNodes(2)},
+			expErr:   ErrLimitExceeded,
+		},
+		"large too many bytes": {
+			envelope: "large",
+			opts:     []ParseOpt{LimitBytes(100)},
+			expErr:   ErrLimitExceeded,
+		},
+		"mapi within limits": {
+			envelope: "with mapi responses",
+			opts:     []ParseOpt{LimitMapiCallbacks(1)},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			crunchyNut, err := hex.DecodeString(envelopes[test.envelope].crunchyNutHexString)
+			require.NoError(t, err)
+			_, err = NewCrunchyNutEnvelopeFromBytes(crunchyNut, test.opts...)
+			if test.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, test.expErr)
+			}
+
+			specialK, err := hex.DecodeString(envelopes[test.envelope].specialKHexString)
+			require.NoError(t, err)
+			_, err = NewSpecialKEnvelopeFromBytes(specialK, test.opts...)
+			if test.expErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, test.expErr)
+			}
+		})
+	}
+}
+
+func TestEnvelopeFromBytes_LimitsWhileParsing(t *testing.T) {
+	_, rawTx := loadAncestryBytes(t, "valid.json")
+	// the limit is exceeded by the second tx, before the truncated flake after it is read.
+	b := append(specialKEnvelope(rawTx, nil, nil, rawTx), 0x05)
+
+	_, err := NewSpecialKEnvelopeFromBytes(b)
+	require.ErrorIs(t, err, ErrOversizedVarInt)
+	_, err = NewSpecialKEnvelopeFromBytes(b, LimitTxs(1))
+	require.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestSpecialKEnvelopeFromBytes_LimitTxsWithNullFlakes(t *testing.T) {
+	_, rawTx := loadAncestryBytes(t, "valid.json")
+
+	tests := map[string]struct {
+		flakes   [][]byte
+		exceeded bool
+	}{
+		"txs with null proofs and mapi responses": {
+			flakes:   [][]byte{rawTx, nil, nil, rawTx, {0x00}, nil, rawTx, nil, nil},
+			exceeded: true,
+		},
+		"null flakes in place of txs": {
+			flakes: [][]byte{rawTx, nil, nil, nil, nil, nil, {0x00}, nil, nil},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewSpecialKEnvelopeFromBytes(specialKEnvelope(test.flakes...), LimitTxs(2))
+			if test.exceeded {
+				require.ErrorIs(t, err, ErrLimitExceeded)
+				return
+			}
+			require.NotErrorIs(t, err, ErrLimitExceeded)
+		})
+	}
+}
+
+func TestDepthTracker(t *testing.T) {
+	// a chain of three txs, each spending the one before.
+	chain := make([]*bt.Tx, 0, 3)
+	prevTxID := "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	for i := 0; i < 3; i++ {
+		tx := bt.NewTx()
+		require.NoError(t, tx.From(prevTxID, 0, "51", 1))
+		tx.AddOutput(&bt.Output{Satoshis: 1, LockingScript: tx.Inputs[0].PreviousTxScript})
+		chain = append(chain, tx)
+		prevTxID = tx.TxID()
+	}
+	txID := func(tx *bt.Tx) [32]byte {
+		var id [32]byte
+		copy(id[:], tx.TxIDBytes())
+		return id
+	}
+
+	tests := map[string]struct {
+		order    []int
+		maxDepth int
+		// failAt is the position in order at which the limit is exceeded, or -1 if it isn't.
+		failAt int
+	}{
+		"oldest first within limit": {
+			order:    []int{0, 1, 2},
+			maxDepth: 3,
+			failAt:   -1,
+		},
+		"newest first within limit": {
+			order:    []int{2, 1, 0},
+			maxDepth: 3,
+			failAt:   -1,
+		},
+		"oldest first too deep": {
+			order:    []int{0, 1, 2},
+			maxDepth: 2,
+			failAt:   2,
+		},
+		"newest first too deep": {
+			order:    []int{2, 1, 0},
+			maxDepth: 2,
+			failAt:   2,
+		},
+		"middle last too deep": {
+			order:    []int{0, 2, 1},
+			maxDepth: 2,
+			failAt:   2,
+		},
+		"no limit": {
+			order:  []int{2, 1, 0},
+			failAt: -1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := newLimits(LimitDepth(test.maxDepth)).newDepthTracker()
+			for i, idx := range test.order {
+				err := d.add(txID(chain[idx]), chain[idx])
+				if i == test.failAt {
+					require.ErrorIs(t, err, ErrLimitExceeded)
+					return
+				}
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package spv
+
+import (
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// ProofStatus describes the outcome of checking the proof of a tx in a VerificationReport.
+type ProofStatus string
+
+// ProofStatus values.
+const (
+	// ProofNotChecked is used when proof verification is switched off.
+	ProofNotChecked ProofStatus = "not checked"
+	// ProofNotSupplied is used for an unconfirmed tx, which relies on its parents being supplied instead.
+	ProofNotSupplied ProofStatus = "not supplied"
+	// ProofValid is used when the tx's merkle proof or BUMP is valid.
+	ProofValid ProofStatus = "valid"
+	// ProofInvalid is used when the tx's merkle proof or BUMP is invalid.
+	ProofInvalid ProofStatus = "invalid"
+)
+
+// VerificationReport details the outcome of every check made while verifying a payment,
+// as returned from PaymentVerifier.VerifyPaymentReport.
+type VerificationReport struct {
+	PaymentTxID string
+	// Fees is nil if fee verification is switched off.
+	Fees *FeeReport
+	// Txs holds a report for the payment tx and each tx in its ancestry, keyed by txid.
+	Txs map[string]*TxReport
+}
+
+// Valid returns true if no check in the report failed.
+func (r *VerificationReport) Valid() bool {
+	if r.Fees != nil && r.Fees.Err != nil {
+		return false
+	}
+	for _, tr := range r.Txs {
+		if tr.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// FeeReport details the fee check made against the payment tx.
+type FeeReport struct {
+	// Paid is the fee paid by the payment tx in satoshis.
+	Paid uint64
+	// Required is the fee required by the fee quote in satoshis.
+	Required uint64
+	Enough   bool
+	Err      error
+}
+
+// TxReport details the checks made against a single tx in the payment or its ancestry.
+type TxReport struct {
+	TxID  string
+	Proof ProofStatus
+	// BlockHash is set when the tx has a valid merkle proof which targets a block hash or header.
+	BlockHash string
+	// BlockHeight is set when the tx is anchored by a BUMP.
+	BlockHeight   uint64
+	Inputs        []*InputReport
+	MapiResponses []*bc.MapiCallback
+	// Err is the first failure found for the tx, if any.
+	Err error
+}
+
+// InputReport details the script check made against a single input of a tx.
+type InputReport struct {
+	Vin                int
+	PreviousTxID       string
+	PreviousTxOutIndex uint32
+	// Checked is false if script verification is switched off or the parent tx wasn't supplied.
+	Checked bool
+	Err     error
+}
@@ -4,26 +4,38 @@ import (
 	"context"
 
 	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript/interpreter/scriptflag"
 	"github.com/pkg/errors"
 
 	"github.com/bsv-blockchain/go-bc"
 )
 
+// defaultScriptFlags are the interpreter flags used for script verification
+// when none are supplied, suitable for post-Genesis transactions.
+const defaultScriptFlags = scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID
+
 type verifyOptions struct {
 	// proofs validation
-	proofs   bool
-	script   bool
-	fees     bool
-	feeQuote *bt.FeeQuote
+	proofs      bool
+	script      bool
+	fees        bool
+	feeQuote    *bt.FeeQuote
+	scriptFlags scriptflag.Flag
+	limits      Limits
+	// proofWorkers is the number of proofs verified concurrently, 0 or 1 for serially.
+	proofWorkers int
 }
 
 // clone will copy the verifyOptions to a new struct and return it.
 func (v *verifyOptions) clone() *verifyOptions {
 	return &verifyOptions{
-		proofs:   v.proofs,
-		fees:     v.fees,
-		script:   v.script,
-		feeQuote: v.feeQuote,
+		proofs:       v.proofs,
+		fees:         v.fees,
+		script:       v.script,
+		feeQuote:     v.feeQuote,
+		scriptFlags:  v.scriptFlags,
+		limits:       v.limits,
+		proofWorkers: v.proofWorkers,
 	}
 }
 
@@ -46,6 +58,19 @@ func NoVerifyProofs() VerifyOpt {
 	}
 }
 
+// VerifyProofsConcurrently will make the verifier check the merkle proofs, and BUMPs, of the
+// ancestry using a pool of worker goroutines rather than one at a time. This speeds up
+// verification of large ancestries when the bc.BlockHeaderChain is a remote service.
+//
+// Each block header is only requested once however many proofs are anchored in that block,
+// and outstanding requests are cancelled as soon as a proof fails. A workers value of 1 or less
+// verifies proofs serially.
+func VerifyProofsConcurrently(workers int) VerifyOpt {
+	return func(opts *verifyOptions) {
+		opts.proofWorkers = workers
+	}
+}
+
 // VerifyFees will make the verifier check the transaction fees
 // of the supplied transaction are enough based on the feeQuote
 // provided.
@@ -77,6 +102,16 @@ func VerifyScript() VerifyOpt {
 	}
 }
 
+// VerifyScriptFlags will ensure the scripts provided in the transaction are valid,
+// executing them with the interpreter flags supplied rather than the defaults
+// (post-Genesis with fork id).
+func VerifyScriptFlags(flags scriptflag.Flag) VerifyOpt {
+	return func(opts *verifyOptions) {
+		opts.script = true
+		opts.scriptFlags = flags
+	}
+}
+
 // NoVerifyScript will switch off script verification and rely on
 // mAPI / node verification when the tx is broadcast.
 func NoVerifyScript() VerifyOpt {
@@ -85,6 +120,19 @@ func NoVerifyScript() VerifyOpt {
 	}
 }
 
+// VerifyLimits will make the verifier reject any payment whose ancestry exceeds the
+// limits set by opts, returning ErrLimitExceeded. Limits are checked while the ancestry
+// is parsed, before any other verification, so oversized input is rejected cheaply.
+//
+// As payments usually come from untrusted sources it is recommended to always set limits.
+func VerifyLimits(opts ...ParseOpt) VerifyOpt {
+	return func(o *verifyOptions) {
+		for _, opt := range opts {
+			opt(&o.limits)
+		}
+	}
+}
+
 // NoVerifySPV will turn off any spv validation for merkle proofs
 // and script validation. This is a helper method that is equivalent to
 // NoVerifyProofs && NoVerifyScripts.
@@ -112,6 +160,8 @@ func VerifySPV() VerifyOpt {
 // you are using; some may return a HeaderJSON response, others may return the blockhash.
 type PaymentVerifier interface {
 	VerifyPayment(ctx context.Context, p *Payment, opts ...VerifyOpt) error
+	VerifyPaymentReport(ctx context.Context, p *Payment, opts ...VerifyOpt) (*VerificationReport, error)
+	VerifyAtomicBeef(ctx context.Context, a *AtomicBeef, opts ...VerifyOpt) error
 	MerkleProofVerifier
 }
 
@@ -119,6 +169,16 @@ type PaymentVerifier interface {
 type MerkleProofVerifier interface {
 	VerifyMerkleProof(ctx context.Context, b []byte) (*MerkleProofValidation, error)
 	VerifyMerkleProofJSON(ctx context.Context, p *bc.MerkleProof) (bool, bool, error)
+	VerifyBUMP(ctx context.Context, bump *bc.BUMP, txid string) (*BUMPValidation, error)
+}
+
+// MerkleRootHeightVerifier is implemented by a bc.BlockHeaderChain which can confirm that a
+// merkle root belongs to the block at a given height on the longest chain. It is required to
+// verify BUMPs, which carry a block height rather than a block hash.
+//
+// Every bc.HeightBlockHeaderChain, such as bc.MemoryBlockHeaderChain, implements it.
+type MerkleRootHeightVerifier interface {
+	IsValidRootForHeight(ctx context.Context, merkleRoot string, height uint64) (bool, error)
 }
 
 type verifier struct {
@@ -133,12 +193,14 @@ type verifier struct {
 // opts control the global behavior of the verifier, and all options are enabled by default, they are:
 // - ancestry verification (proofs checked etc.)
 // - fees checked, ensuring the root tx covers enough fees
-// - script verification which checks the script is correct (not currently implemented).
+// - script verification which executes each input's unlocking script against the
+// locking script of the output it spends.
 func NewPaymentVerifier(bhc bc.BlockHeaderChain, opts ...VerifyOpt) (PaymentVerifier, error) {
 	o := &verifyOptions{
-		proofs: true,
-		fees:   false,
-		script: true,
+		proofs:      true,
+		fees:        false,
+		script:      true,
+		scriptFlags: defaultScriptFlags,
 	}
 	for _, opt := range opts {
 		opt(o)
@@ -0,0 +1,19 @@
+package spv
+
+import (
+	"context"
+)
+
+// VerifyAtomicBeef verifies the subject transaction of an Atomic BEEF as the payment, using the
+// rest of the Beef as its ancestry. The Atomic BEEF is rejected if it contains any transaction
+// which is not an ancestor of the subject.
+func (v *verifier) VerifyAtomicBeef(ctx context.Context, a *AtomicBeef, opts ...VerifyOpt) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	return v.VerifyPayment(ctx, &Payment{
+		PaymentTx: a.SubjectTx(),
+		Beef:      a.Beef,
+	}, opts...)
+}
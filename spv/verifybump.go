@@ -0,0 +1,64 @@
+package spv
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/bsv-blockchain/go-bc"
+)
+
+// BUMPValidation is the result of successfully verifying a BUMP, identifying the block
+// on the longest chain which the transaction was mined in.
+type BUMPValidation struct {
+	TxID       string
+	MerkleRoot string
+	// BlockHash is only set when the bc.BlockHeaderChain is a bc.HeightBlockHeaderChain,
+	// as a MerkleRootHeightVerifier can only confirm the merkle root.
+	BlockHash   string
+	BlockHeight uint64
+}
+
+// VerifyBUMP verifies that the BUMP computes, from txid, the merkle root of the block at
+// the BUMP's height on the longest chain. The bc.BlockHeaderChain supplied to the verifier
+// must be a MerkleRootHeightVerifier, else ErrNoMerkleRootHeightVerifier is returned.
+func (v *verifier) VerifyBUMP(ctx context.Context, bump *bc.BUMP, txid string) (*BUMPValidation, error) {
+	hv, ok := v.bhc.(MerkleRootHeightVerifier)
+	if !ok {
+		return nil, ErrNoMerkleRootHeightVerifier
+	}
+	if bump == nil {
+		return nil, ErrInvalidProof
+	}
+	root, err := bump.CalculateRootGivenTxid(txid)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidProof, "txid %s: %s", txid, err)
+	}
+	res := &BUMPValidation{
+		TxID:        txid,
+		MerkleRoot:  root,
+		BlockHeight: bump.BlockHeight,
+	}
+
+	hbc, ok := v.bhc.(bc.HeightBlockHeaderChain)
+	if !ok {
+		valid, err := hv.IsValidRootForHeight(ctx, root, bump.BlockHeight)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to verify merkle root at height %d", bump.BlockHeight)
+		}
+		if !valid {
+			return nil, errors.Wrapf(ErrInvalidProof, "merkle root %s is not that of the block at height %d", root, bump.BlockHeight)
+		}
+		return res, nil
+	}
+
+	header, err := hbc.BlockHeaderByHeight(ctx, bump.BlockHeight)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get header at height %d", bump.BlockHeight)
+	}
+	if header.HashMerkleRootStr() != root {
+		return nil, errors.Wrapf(ErrInvalidProof, "merkle root %s is not that of the block at height %d", root, bump.BlockHeight)
+	}
+	res.BlockHash = header.HashStr()
+	return res, nil
+}
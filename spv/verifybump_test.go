@@ -0,0 +1,118 @@
+package spv_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+	"github.com/bsv-blockchain/go-bc/spv"
+)
+
+func TestVerifier_VerifyBUMP(t *testing.T) {
+	beef, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+	bump := beef.BUMPs[0]
+
+	chain := brc62HeaderChain(t)
+	header, err := chain.BlockHeaderByHeight(context.Background(), brc62Height)
+	require.NoError(t, err)
+	blockHash := hex.EncodeToString(bt.ReverseBytes(bc.Sha256Sha256(header.Bytes())))
+
+	tests := map[string]struct {
+		bhc    bc.BlockHeaderChain
+		bump   *bc.BUMP
+		txid   string
+		exp    *spv.BUMPValidation
+		expErr error
+	}{
+		"valid bump passes": {
+			bhc:  chain,
+			bump: bump,
+			txid: brc62AnchoredTxID,
+			exp: &spv.BUMPValidation{
+				TxID:        brc62AnchoredTxID,
+				MerkleRoot:  brc62Root,
+				BlockHash:   blockHash,
+				BlockHeight: brc62Height,
+			},
+		},
+		"txid not in bump fails": {
+			bhc:    chain,
+			bump:   bump,
+			txid:   beef.Txs[1].Tx.TxID(),
+			expErr: spv.ErrInvalidProof,
+		},
+		"bump for a different height fails": {
+			bhc:    chain,
+			bump:   &bc.BUMP{BlockHeight: brc62Height - 1, Path: bump.Path},
+			txid:   brc62AnchoredTxID,
+			expErr: spv.ErrInvalidProof,
+		},
+		"bump above the chain tip fails": {
+			bhc:    chain,
+			bump:   &bc.BUMP{BlockHeight: brc62Height + 1, Path: bump.Path},
+			txid:   brc62AnchoredTxID,
+			expErr: bc.ErrHeaderNotFound,
+		},
+		"header chain which only verifies roots by height passes without the block hash": {
+			bhc:  &mockRootHeightClient{roots: map[uint64]string{brc62Height: brc62Root}},
+			bump: bump,
+			txid: brc62AnchoredTxID,
+			exp: &spv.BUMPValidation{
+				TxID:        brc62AnchoredTxID,
+				MerkleRoot:  brc62Root,
+				BlockHeight: brc62Height,
+			},
+		},
+		"go-sdk chain tracker passes without the block hash": {
+			bhc:  bc.NewChainTrackerHeaderChain(bc.NewChainTracker(chain)),
+			bump: bump,
+			txid: brc62AnchoredTxID,
+			exp: &spv.BUMPValidation{
+				TxID:        brc62AnchoredTxID,
+				MerkleRoot:  brc62Root,
+				BlockHeight: brc62Height,
+			},
+		},
+		"go-sdk chain tracker with a bump for a different height fails": {
+			bhc:    bc.NewChainTrackerHeaderChain(bc.NewChainTracker(chain)),
+			bump:   &bc.BUMP{BlockHeight: brc62Height - 1, Path: bump.Path},
+			txid:   brc62AnchoredTxID,
+			expErr: spv.ErrInvalidProof,
+		},
+		"root not at the height of a header chain which only verifies roots fails": {
+			bhc:    &mockRootHeightClient{roots: map[uint64]string{brc62Height + 1: brc62Root}},
+			bump:   bump,
+			txid:   brc62AnchoredTxID,
+			expErr: spv.ErrInvalidProof,
+		},
+		"header chain which cannot verify by height fails": {
+			bhc:    &mockBlockHeaderClient{},
+			bump:   bump,
+			txid:   brc62AnchoredTxID,
+			expErr: spv.ErrNoMerkleRootHeightVerifier,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			v, err := spv.NewMerkleProofVerifier(test.bhc)
+			require.NoError(t, err)
+
+			res, err := v.VerifyBUMP(context.Background(), test.bump, test.txid)
+			if test.expErr != nil {
+				require.Error(t, err)
+				require.Equal(t, test.expErr, errors.Cause(err))
+				require.Nil(t, res)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, res)
+		})
+	}
+}
@@ -2,44 +2,49 @@ package spv
 
 import (
 	"context"
-	"encoding/hex"
-	"fmt"
 
 	"github.com/bsv-blockchain/go-bt/v2"
 
 	"github.com/bsv-blockchain/go-bc"
 )
 
-const (
-	txOrIDFlag      byte = 1 << iota // 1 << 0 which is 00000001
-	targetTypeFlag1                  // 1 << 1 which is 00000010
-	targetTypeFlag2                  // 1 << 2 which is 00000100
-	proofTypeFlag                    // 1 << 3 which is 00001000
-	compositeFlag                    // 1 << 4 which is 00010000
-
-	targetTypeFlags = targetTypeFlag1 | targetTypeFlag2
-)
-
 // MerkleProofValidation is a wrapper for the response of a validation operation.
 type MerkleProofValidation struct {
 	TxID         string
 	Valid        bool
 	IsLastInTree bool
+	// BlockHash is set when the proof targets a block hash or a block header.
+	BlockHash string
+	// TxIDs holds the ids of all the transactions of a composite proof, in which case
+	// TxID is the first of them.
+	TxIDs []string
+}
+
+// proves returns true if txID is one of the txs the validated proof proves.
+func (m *MerkleProofValidation) proves(txID string) bool {
+	if m.TxID == txID {
+		return true
+	}
+	for _, id := range m.TxIDs {
+		if id == txID {
+			return true
+		}
+	}
+	return false
 }
 
 // VerifyMerkleProof verifies a Merkle Proof in standard byte format.
 func (v *verifier) VerifyMerkleProof(ctx context.Context, proof []byte) (*MerkleProofValidation, error) {
-	mpb, err := parseBinaryMerkleProof(proof)
+	mp, err := bc.NewMerkleProofFromBytes(proof)
 	if err != nil {
 		return nil, err
 	}
-
-	err = validateTxOrID(mpb.flags, mpb.txOrID)
-	if err != nil {
-		return nil, err
+	if mp.Composite || mp.ProofType == "tree" {
+		// tree and composite proofs are calculated by bc.
+		return v.verifyMerkleProof(ctx, mp)
 	}
 
-	txid, err := txidFromTxOrID(mpb.txOrID)
+	txid, err := txidFromTxOrID(mp.TxOrID)
 	if err != nil {
 		return nil, err
 	}
@@ -49,43 +54,37 @@ func (v *verifier) VerifyMerkleProof(ctx context.Context, proof []byte) (*Merkle
 	}
 
 	var merkleRoot string
-	switch mpb.flags & targetTypeFlags {
-	// if bits 1 and 2 of flags are NOT set, target should contain a block hash (32 bytes)
-	case 0:
+	switch mp.TargetType {
+	case "":
 		// The `target` field contains a block hash
 		var blockHeader *bc.BlockHeader
-		blockHeader, err = v.bhc.BlockHeader(ctx, mpb.target)
+		blockHeader, err = v.bhc.BlockHeader(ctx, mp.Target)
 		if err != nil {
 			return response, err
 		}
 
 		merkleRoot = blockHeader.HashMerkleRootStr()
+		response.BlockHash = mp.Target
 
-	// if bit 2 of flags is set, target should contain a merkle root (32 bytes)
-	case 4:
+	case "merkleRoot":
 		// the `target` field contains a merkle root
-		merkleRoot = mpb.target
+		merkleRoot = mp.Target
 
-	// if bit 1 of flags is set, target should contain a block header (80 bytes)
-	case 2:
+	case h:
 		// The `target` field contains a block header
-		merkleRoot, err = bc.ExtractMerkleRootFromBlockHeader(mpb.target)
+		var blockHeader *bc.BlockHeader
+		blockHeader, err = bc.NewBlockHeaderFromStr(mp.Target)
 		if err != nil {
 			return response, err
 		}
 
-	default:
-		return response, ErrInvalidMerkleFlags
-	}
+		merkleRoot = blockHeader.HashMerkleRootStr()
+		response.BlockHash = blockHeader.HashStr()
 
-	if mpb.flags&proofTypeFlag == 1 {
+	default:
 		return response, ErrInvalidMerkleFlags
 	}
 
-	if mpb.flags&compositeFlag == 1 {
-		return response, ErrInvalidMerkleFlags // composite proof type not supported
-	}
-
 	if txid == "" {
 		return response, ErrMissingTxidInProof
 	}
@@ -94,17 +93,26 @@ func (v *verifier) VerifyMerkleProof(ctx context.Context, proof []byte) (*Merkle
 		return response, ErrMissingRootInProof
 	}
 
-	valid, isLastInTree, err := verifyProof(txid, merkleRoot, mpb.index, mpb.nodes)
+	valid, isLastInTree, err := verifyProof(txid, merkleRoot, mp.Index, mp.Nodes)
 
 	return &MerkleProofValidation{
 		TxID:         txid,
 		Valid:        valid,
 		IsLastInTree: isLastInTree,
+		BlockHash:    response.BlockHash,
 	}, err
 }
 
 // VerifyMerkleProofJSON verifies a Merkle Proof in standard JSON format.
 func (v *verifier) VerifyMerkleProofJSON(ctx context.Context, proof *bc.MerkleProof) (bool, bool, error) {
+	if proof.Composite || proof.ProofType == "tree" {
+		mpv, err := v.verifyMerkleProof(ctx, proof)
+		if err != nil {
+			return false, false, err
+		}
+		return mpv.Valid, mpv.IsLastInTree, nil
+	}
+
 	txid, err := txidFromTxOrID(proof.TxOrID)
 	if err != nil {
 		return false, false, err
@@ -143,10 +151,6 @@ func (v *verifier) VerifyMerkleProofJSON(ctx context.Context, proof *bc.MerklePr
 		return false, false, ErrOnlyMerkleBranchSupported // merkle tree proof type not supported
 	}
 
-	if proof.Composite { // OR if (proof.composite && proof.composite != false)
-		return false, false, ErrOnlySingleProofSupported // composite proof type not supported
-	}
-
 	if txid == "" {
 		return false, false, ErrTxidMissing
 	}
@@ -158,6 +162,75 @@ func (v *verifier) VerifyMerkleProofJSON(ctx context.Context, proof *bc.MerklePr
 	return verifyProof(txid, merkleRoot, proof.Index, proof.Nodes)
 }
 
+// verifyMerkleProof verifies a tree or composite proof, calculating its merkle root with
+// bc and comparing it to that of the target.
+func (v *verifier) verifyMerkleProof(ctx context.Context, proof *bc.MerkleProof) (*MerkleProofValidation, error) {
+	if proof.ProofType != "" && proof.ProofType != "branch" && proof.ProofType != "tree" {
+		return nil, ErrOnlyMerkleBranchSupported
+	}
+	txids, err := proof.TxIDs()
+	if err != nil {
+		return nil, err
+	}
+	response := &MerkleProofValidation{
+		TxID: txids[0],
+	}
+	if proof.Composite {
+		response.TxIDs = txids
+	}
+
+	var merkleRoot string
+	switch proof.TargetType {
+	case "", "hash":
+		// The `target` field contains a block hash
+		if len(proof.Target) != 64 {
+			return response, ErrInvalidTarget
+		}
+		var blockHeader *bc.BlockHeader
+		blockHeader, err = v.bhc.BlockHeader(ctx, proof.Target)
+		if err != nil {
+			return response, err
+		}
+		merkleRoot = blockHeader.HashMerkleRootStr()
+		response.BlockHash = proof.Target
+
+	case h:
+		// The `target` field contains a block header
+		var blockHeader *bc.BlockHeader
+		blockHeader, err = bc.NewBlockHeaderFromStr(proof.Target)
+		if err != nil {
+			return response, err
+		}
+		merkleRoot = blockHeader.HashMerkleRootStr()
+		response.BlockHash = blockHeader.HashStr()
+
+	case "merkleRoot":
+		// the `target` field contains a merkle root
+		if len(proof.Target) != 64 {
+			return response, ErrInvalidTarget
+		}
+		merkleRoot = proof.Target
+
+	default:
+		return response, ErrInvalidTargetType
+	}
+
+	root, isLastInTree, err := proof.CalculateRoot()
+	if err != nil {
+		return response, err
+	}
+	response.Valid = root == merkleRoot
+	response.IsLastInTree = isLastInTree
+	return response, nil
+}
+
+// verifyBUMP checks the BUMP computes a merkle root, from the txid, which is that of the block
+// at the BUMP's height.
+func (v *verifier) verifyBUMP(ctx context.Context, bump *bc.BUMP, txID string) error {
+	_, err := v.VerifyBUMP(ctx, bump, txID)
+	return err
+}
+
 func verifyProof(c, merkleRoot string, index uint64, nodes []string) (bool, bool, error) {
 	isLastInTree := true
 
@@ -203,20 +276,6 @@ func verifyProof(c, merkleRoot string, index uint64, nodes []string) (bool, bool
 	return c == merkleRoot, isLastInTree, nil
 }
 
-func validateTxOrID(flags byte, txOrID string) error {
-	// The `txOrId` field contains a full transaction
-	if len(txOrID) > 64 && flags&txOrIDFlag == 0 {
-		return ErrExpectingTxidButGotTx
-	}
-
-	// The `txOrId` field contains a transaction ID
-	if len(txOrID) == 64 && flags&txOrIDFlag == 1 {
-		return ErrExpectingTxButGotTxid
-	}
-
-	return nil
-}
-
 func txidFromTxOrID(txOrID string) (string, error) {
 	// The `txOrId` field contains a transaction ID
 	if len(txOrID) == 64 {
@@ -235,90 +294,3 @@ func txidFromTxOrID(txOrID string) (string, error) {
 
 	return "", ErrInvalidTxOrIDLength
 }
-
-type merkleProofBinary struct {
-	flags  byte
-	index  uint64
-	txOrID string
-	target string
-	nodes  []string
-}
-
-func parseBinaryMerkleProof(proof []byte) (*merkleProofBinary, error) {
-	mpb := &merkleProofBinary{}
-
-	var offset int
-
-	// flags is first byte
-	mpb.flags = proof[offset]
-	offset++
-
-	// index is the next varint after the 1st byte
-	index, size := bt.NewVarIntFromBytes(proof[offset:])
-	mpb.index = uint64(index)
-	offset += size
-
-	var txLength bt.VarInt
-	// if bit 1 of flags is NOT set, txOrId should contain txid (= 32 bytes)
-	if mpb.flags&1 == 0 {
-		txLength = 32
-	}
-
-	// if bit 1 of flags is set, txOrId should contain tx hex (> 32 bytes)
-	if mpb.flags&1 == 1 {
-		// txLength is the next varint after the 1st byte + index size
-		txLength, size = bt.NewVarIntFromBytes(proof[offset:])
-		offset += size
-		if txLength <= 32 {
-			return nil, ErrInvalidTxLength
-		}
-	}
-
-	// txOrID is the next txLength bytes after 1st byte + index size (+ txLength size)
-	mpb.txOrID = hex.EncodeToString(bt.ReverseBytes(proof[offset : offset+int(txLength)]))
-	offset += int(txLength)
-
-	switch mpb.flags & targetTypeFlags {
-	// if bits 1 and 2 of flags are NOT set, target should contain a block hash (32 bytes)
-	// if bit 2 of flags is set, target should contain a merkle root (32 bytes)
-	case 0, 4:
-		mpb.target = hex.EncodeToString(bt.ReverseBytes(proof[offset : offset+32]))
-		offset += 32
-
-	// if bit 1 of flags is set, target should contain a block header (80 bytes)
-	case 2:
-		mpb.target = hex.EncodeToString(bt.ReverseBytes(proof[offset : offset+80]))
-		offset += 80
-
-	default:
-		return nil, ErrInvalidMerkleFlags
-	}
-
-	nodeCount, size := bt.NewVarIntFromBytes(proof[offset:])
-	offset += size
-
-	if mpb.index >= 1<<nodeCount {
-		return nil, ErrInvalidProof
-	}
-
-	for i := 0; i < int(nodeCount); i++ { //nolint:gosec // G115: Safe conversion - nodeCount is validated by proof structure
-		t := proof[offset]
-		offset++
-
-		var n string
-		switch t {
-		case 0:
-			n = hex.EncodeToString(bt.ReverseBytes(proof[offset : offset+32]))
-			offset += 32
-		case 1:
-			n = "*"
-
-		default:
-			return nil, fmt.Errorf("%w at index %d", ErrInvalidNodeType, i)
-		}
-
-		mpb.nodes = append(mpb.nodes, n)
-	}
-
-	return mpb, nil
-}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -52,3 +53,82 @@ func TestVerifyMerkleProof(t *testing.T) {
 		assert.True(t, mpv.Valid)
 	})
 }
+
+func TestVerifyMerkleProof_Malformed(t *testing.T) {
+	t.Parallel()
+
+	branch, err := (&bc.MerkleProof{
+		Index:  1,
+		TxOrID: "ffeff11c25cde7c06d407490d81ef4d0db64aad6ab3d14393530701561a465ef",
+		Target: "75edb0a69eb195cdd81e310553aa4d25e18450e08f168532a2c2e9cf447bf169",
+		Nodes:  []string{"b9ef07a62553ef8b0898a79c291b92c60f7932260888bde0dab2dd2610d8668e"},
+	}).Bytes()
+	require.NoError(t, err)
+	tree := append([]byte{branch[0] | 1<<3}, branch[1:]...)
+	v, err := spv.NewMerkleProofVerifier(&mockBlockHeaderChain{})
+	require.NoError(t, err)
+
+	// branch and tree proofs are decoded the same way so fail with the same errors.
+	for name, proof := range map[string][]byte{"branch": branch, "tree": tree} {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.VerifyMerkleProof(context.Background(), proof[:len(proof)-1])
+			require.ErrorIs(t, err, spv.ErrTruncatedMerkleProof)
+			require.ErrorIs(t, err, bc.ErrTruncatedMerkleProof)
+
+			_, err = v.VerifyMerkleProof(context.Background(), append([]byte{proof[0] | 1<<1 | 1<<2}, proof[1:]...))
+			require.ErrorIs(t, err, spv.ErrInvalidMerkleFlags)
+			require.ErrorIs(t, err, bc.ErrInvalidMerkleFlags)
+
+			nodeType := len(proof) - 33
+			invalid := append([]byte{}, proof...)
+			invalid[nodeType] = 2
+			_, err = v.VerifyMerkleProof(context.Background(), invalid)
+			require.ErrorIs(t, err, spv.ErrInvalidNodeType)
+		})
+	}
+}
+
+func TestVerifyCompositeMerkleProof(t *testing.T) {
+	t.Parallel()
+
+	txids := make([]*chainhash.Hash, 0, 6)
+	for i := 0; i < 6; i++ {
+		txids = append(txids, &chainhash.Hash{byte(i + 1)})
+	}
+	merkles := bc.BuildMerkleTreeStoreChainHash(txids)
+	bump, err := bc.NewBUMPFromMerkleTreeAndIndices(100, merkles, []uint64{1, 4, 5})
+	require.NoError(t, err)
+	proofJSON, err := bc.NewCompositeMerkleProofFromBUMP(context.Background(), bump,
+		[]string{txids[1].String(), txids[4].String(), txids[5].String()}, nil)
+	require.NoError(t, err)
+
+	v, _ := spv.NewMerkleProofVerifier(&mockBlockHeaderChain{})
+
+	t.Run("JSON", func(t *testing.T) {
+		valid, isLastInTree, err := v.VerifyMerkleProofJSON(context.Background(), proofJSON)
+		require.NoError(t, err)
+		assert.True(t, isLastInTree)
+		assert.True(t, valid)
+	})
+
+	t.Run("Bytes", func(t *testing.T) {
+		proof, err := proofJSON.Bytes()
+		require.NoError(t, err)
+		mpv, err := v.VerifyMerkleProof(context.Background(), proof)
+		require.NoError(t, err)
+		assert.True(t, mpv.IsLastInTree)
+		assert.True(t, mpv.Valid)
+		assert.Equal(t, txids[1].String(), mpv.TxID)
+		assert.Equal(t, []string{txids[1].String(), txids[4].String(), txids[5].String()}, mpv.TxIDs)
+	})
+
+	t.Run("wrong root", func(t *testing.T) {
+		wrong := *proofJSON
+		wrong.Target = txids[0].String()
+		proof, err := wrong.Bytes()
+		require.NoError(t, err)
+		mpv, err := v.VerifyMerkleProof(context.Background(), proof)
+		require.NoError(t, err)
+		assert.False(t, mpv.Valid)
+	})
+}
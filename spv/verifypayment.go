@@ -3,23 +3,51 @@ package spv
 import (
 	"context"
 
+	"github.com/bsv-blockchain/go-bt/v2"
 	"github.com/pkg/errors"
 )
 
 // VerifyPayment is a method for parsing a binary payment transaction and its corresponding ancestry in binary.
 // It will return the paymentTx struct if all validations pass.
 func (v *verifier) VerifyPayment(ctx context.Context, p *Payment, opts ...VerifyOpt) error {
+	_, err := v.verifyPayment(ctx, p, v.options(opts...), true)
+	return err
+}
+
+// VerifyPaymentReport runs the same checks as VerifyPayment but, rather than stopping at the
+// first failure, carries on and records the outcome of every check in a VerificationReport.
+//
+// The error returned is the first verification failure found, or nil if the payment is valid.
+// The report is returned for any payment whose ancestry could be parsed, even when invalid.
+func (v *verifier) VerifyPaymentReport(ctx context.Context, p *Payment, opts ...VerifyOpt) (*VerificationReport, error) {
+	return v.verifyPayment(ctx, p, v.options(opts...), false)
+}
+
+// options returns the verifier options overridden by opts.
+func (v *verifier) options(opts ...VerifyOpt) *verifyOptions {
 	o := v.opts.clone()
 	for _, opt := range opts {
 		opt(o)
 	}
+	return o
+}
+
+// verifyPayment runs the checks enabled in o against the payment, recording each outcome in
+// the report returned. If failFast is set it stops at the first failure.
+func (v *verifier) verifyPayment(ctx context.Context, p *Payment, o *verifyOptions, failFast bool) (*VerificationReport, error) {
 	if o.proofs && v == nil {
-		return errors.New("Merkle Proof Verifier is required when proofs is set")
+		return nil, errors.New("Merkle Proof Verifier is required when proofs is set")
 	}
 
-	aa, err := parseAncestry(p.Ancestry)
+	var aa map[[32]byte]*ancestry
+	var err error
+	if p.Beef != nil {
+		aa, err = p.Beef.ancestry(&o.limits)
+	} else {
+		aa, err = parseAncestry(p.Ancestry, &o.limits)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var paymentTxID [32]byte
@@ -27,90 +55,172 @@ func (v *verifier) VerifyPayment(ctx context.Context, p *Payment, opts ...Verify
 	aa[paymentTxID] = &ancestry{
 		Tx: p.PaymentTx,
 	}
+
+	report := &VerificationReport{
+		PaymentTxID: p.PaymentTx.TxID(),
+		Txs:         make(map[string]*TxReport, len(aa)),
+	}
+	var firstErr error
+
 	if o.fees {
 		if o.feeQuote == nil {
-			return ErrNoFeeQuoteSupplied
+			return report, ErrNoFeeQuoteSupplied
 		}
-		for i, input := range p.PaymentTx.Inputs {
-			var inputID [32]byte
-			copy(inputID[:], input.PreviousTxID())
-			parent, ok := aa[inputID]
-			if !ok {
-				return errors.Wrapf(ErrNoFeeQuoteSupplied, "missing tx for input %d", i)
+		report.Fees = verifyFees(p.PaymentTx, aa, o.feeQuote)
+		if report.Fees.Err != nil {
+			if failFast {
+				return report, report.Fees.Err
 			}
-
-			out := parent.Tx.OutputIdx(int(input.PreviousTxOutIndex))
-			if out == nil {
-				return ErrMissingOutput
-			}
-
-			input.PreviousTxSatoshis = out.Satoshis
-		}
-		ok, err := p.PaymentTx.IsFeePaidEnough(o.feeQuote)
-		if err != nil {
-			return err
+			firstErr = report.Fees.Err
 		}
-		if !ok {
-			return ErrFeePaidNotEnough
+	}
+	var proofs map[*ancestry]*proofResult
+	if o.proofs && o.proofWorkers > 1 {
+		var err error
+		proofs, err = v.verifyProofs(ctx, aa, o.proofWorkers, failFast)
+		if err != nil && failFast {
+			return report, err
 		}
 	}
 	for _, a := range aa {
-		inputsToCheck := make(map[[32]byte]*extendedInput)
-		if len(a.Tx.Inputs) == 0 {
-			return ErrNoTxInputsToVerify
-		}
-		for idx, input := range a.Tx.Inputs {
-			var inputID [32]byte
-			copy(inputID[:], input.PreviousTxID())
-			inputsToCheck[inputID] = &extendedInput{
-				input: input,
-				vin:   idx,
+		tr := v.verifyTx(ctx, a, aa, proofs, o, failFast)
+		report.Txs[tr.TxID] = tr
+		if tr.Err != nil {
+			if failFast {
+				return report, tr.Err
 			}
-		}
-		// if we have a proof, check it.
-		if o.proofs {
-			if a.Proof == nil {
-				for inputID := range inputsToCheck {
-					// check if we have that ancestry, if not validation fail.
-					if aa[inputID] == nil {
-						return ErrProofOrInputMissing
-					}
-				}
-			} else {
-				// check proof.
-				response, err := v.VerifyMerkleProof(ctx, a.Proof)
-				if response == nil {
-					return ErrInvalidProof
-				}
-				if response.TxID != "" && response.TxID != a.Tx.TxID() {
-					return ErrTxIDMismatch
-				}
-				if err != nil || !response.Valid {
-					return ErrInvalidProof
-				}
+			if firstErr == nil {
+				firstErr = tr.Err
 			}
 		}
-		if o.script {
-			// otherwise check the inputs.
-			for inputID, extendedInput := range inputsToCheck {
-				input := extendedInput.input
+	}
+	return report, firstErr
+}
+
+// verifyFees checks the payment tx pays enough fees according to the feeQuote.
+//
+// The previous output of each input is filled in on a clone, so tx isn't modified.
+func verifyFees(tx *bt.Tx, aa map[[32]byte]*ancestry, feeQuote *bt.FeeQuote) *FeeReport {
+	fr := &FeeReport{}
+	tx = tx.Clone()
+	for i, input := range tx.Inputs {
+		parent, ok := aa[previousTxID(input)]
+		if !ok {
+			fr.Err = errors.Wrapf(ErrNoFeeQuoteSupplied, "missing tx for input %d", i)
+			return fr
+		}
+
+		out := parent.Tx.OutputIdx(int(input.PreviousTxOutIndex))
+		if out == nil {
+			fr.Err = ErrMissingOutput
+			return fr
+		}
+
+		input.PreviousTxSatoshis = out.Satoshis
+		input.PreviousTxScript = out.LockingScript
+	}
+	if in, out := tx.TotalInputSatoshis(), tx.TotalOutputSatoshis(); in >= out {
+		fr.Paid = in - out
+	}
+	if fees, err := tx.EstimateFeesPaid(feeQuote); err == nil {
+		fr.Required = fees.TotalFeePaid
+	}
+	ok, err := tx.IsFeePaidEnough(feeQuote)
+	if err != nil {
+		fr.Err = err
+		return fr
+	}
+	fr.Enough = ok
+	if !ok {
+		fr.Err = ErrFeePaidNotEnough
+	}
+	return fr
+}
+
+// verifyTx checks the proof and scripts of a single tx in the ancestry, as enabled in o.
+// If failFast is set it stops at the first failure.
+//
+// The proof is only checked if its result isn't already in proofs.
+func (v *verifier) verifyTx(ctx context.Context, a *ancestry, aa map[[32]byte]*ancestry, proofs map[*ancestry]*proofResult,
+	o *verifyOptions, failFast bool,
+) *TxReport {
+	tr := &TxReport{
+		TxID:          a.Tx.TxID(),
+		Proof:         ProofNotChecked,
+		MapiResponses: a.MapiResponses,
+	}
+	if a.BUMP != nil {
+		tr.BlockHeight = a.BUMP.BlockHeight
+	}
+	fail := func(err error) bool {
+		if tr.Err == nil {
+			tr.Err = err
+		}
+		return failFast
+	}
+
+	if len(a.Tx.Inputs) == 0 {
+		tr.Err = ErrNoTxInputsToVerify
+		return tr
+	}
+	// if we have a proof, check it.
+	if o.proofs {
+		if a.BUMP == nil && a.Proof == nil {
+			tr.Proof = ProofNotSupplied
+			for _, input := range a.Tx.Inputs {
 				// check if we have that ancestry, if not validation fail.
-				if aa[inputID] == nil {
-					if a.Proof == nil && o.proofs {
-						return ErrProofOrInputMissing
+				if aa[previousTxID(input)] == nil {
+					if fail(ErrProofOrInputMissing) {
+						return tr
 					}
-					continue
-				}
-				if len(aa[inputID].Tx.Outputs) <= int(input.PreviousTxOutIndex) {
-					return ErrInputRefsOutOfBoundsOutput
+					break
 				}
-				lockingScript := aa[inputID].Tx.Outputs[input.PreviousTxOutIndex].LockingScript
-				unlockingScript := input.UnlockingScript
-				if !verifyInputOutputPair(a.Tx, lockingScript, unlockingScript) {
-					return ErrPaymentNotVerified
+			}
+		} else {
+			r, ok := proofs[a]
+			if !ok {
+				r = v.checkProof(ctx, a, tr.TxID)
+			}
+			tr.Proof = r.status
+			tr.BlockHash = r.blockHash
+			if r.err != nil && fail(r.err) {
+				return tr
+			}
+		}
+	}
+
+	tr.Inputs = make([]*InputReport, 0, len(a.Tx.Inputs))
+	for vin, input := range a.Tx.Inputs {
+		ir := &InputReport{
+			Vin:                vin,
+			PreviousTxID:       input.PreviousTxIDStr(),
+			PreviousTxOutIndex: input.PreviousTxOutIndex,
+		}
+		tr.Inputs = append(tr.Inputs, ir)
+		if !o.script {
+			continue
+		}
+		parent, ok := aa[previousTxID(input)]
+		// check if we have that ancestry, if not validation fail.
+		if !ok {
+			if a.Proof == nil && a.BUMP == nil && o.proofs {
+				ir.Err = ErrProofOrInputMissing
+				if fail(ir.Err) {
+					return tr
 				}
 			}
+			continue
+		}
+		ir.Checked = true
+		if len(parent.Tx.Outputs) <= int(input.PreviousTxOutIndex) {
+			ir.Err = ErrInputRefsOutOfBoundsOutput
+		} else {
+			ir.Err = verifyInputOutputPair(a.Tx, vin, parent.Tx.Outputs[input.PreviousTxOutIndex], o.scriptFlags)
+		}
+		if ir.Err != nil && fail(ir.Err) {
+			return tr
 		}
 	}
-	return nil
+
+	return tr
 }
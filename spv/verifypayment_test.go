@@ -8,6 +8,10 @@ import (
 	"testing"
 
 	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/bscript/interpreter/scriptflag"
+	"github.com/bsv-blockchain/go-bt/v2/unlocker"
+	bec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
@@ -31,8 +35,6 @@ func (m *mockBlockHeaderClient) BlockHeader(ctx context.Context, blockHash strin
 
 // TestSPVEnvelope_VerifyPayment tests the VerifyPayment method of the SPV envelope.
 func TestSPVEnvelope_VerifyPayment(t *testing.T) {
-	t.Skip("this is failing due to bsv-blockchain/go-bt vs libsv/go-bt incompatibility")
-
 	tests := map[string]struct {
 		testFile string
 		// setupOpts are passed to the NewVerifier func.
@@ -167,14 +169,14 @@ func TestSPVEnvelope_VerifyPayment(t *testing.T) {
 		"wrong merkle proof supplied via hex with otherwise correct input errors": {
 			exp:          false,
 			testFile:     "invalid_wrong_merkle_proof_hex",
-			expErr:       spv.ErrTxIDMismatch,
-			expErrBinary: spv.ErrTxIDMismatch,
+			expErr:       spv.ErrInvalidProof,
+			expErrBinary: spv.ErrInvalidProof,
 		},
 		"wrong merkle proof supplied with otherwise correct input errors": {
 			exp:          false,
 			testFile:     "invalid_wrong_merkle_proof",
-			expErr:       spv.ErrTxIDMismatch,
-			expErrBinary: spv.ErrTxIDMismatch,
+			expErr:       spv.ErrInvalidProof,
+			expErrBinary: spv.ErrInvalidProof,
 		},
 		"valid multiple layer tx passes": {
 			exp:      true,
@@ -257,8 +259,6 @@ func TestSPVEnvelope_VerifyPayment(t *testing.T) {
 
 // TestVerifyAncestryBinary tests the VerifyPayment method of the SPV envelope with binary data.
 func TestVerifyAncestryBinary(t *testing.T) {
-	t.Skip("this is failing due to bsv-blockchain/go-bt vs libsv/go-bt incompatibility")
-
 	tests := map[string]struct {
 		testFile string
 		// setupOpts are passed to the NewVerifier func.
@@ -328,3 +328,224 @@ func TestVerifyAncestryBinary(t *testing.T) {
 		})
 	}
 }
+
+// newSignedPayment builds a parent tx paying to the key, and a payment tx spending it signed with signer.
+func newSignedPayment(t *testing.T, owner, signer *bec.PrivateKey) (*bt.Tx, []byte) {
+	t.Helper()
+
+	lockingScript, err := bscript.NewP2PKHFromPubKeyEC(owner.PubKey())
+	require.NoError(t, err)
+
+	parent := bt.NewTx()
+	require.NoError(t, parent.From(
+		"b7b0650a7c3a1bd4716369783876348b59f5404784970192cec1996e86950576", 0,
+		lockingScript.String(), 2000,
+	))
+	require.NoError(t, parent.AddP2PKHOutputFromScript(lockingScript, 1000))
+	require.NoError(t, parent.FillAllInputs(context.Background(), &unlocker.Getter{PrivateKey: owner}))
+
+	paymentTx := bt.NewTx()
+	require.NoError(t, paymentTx.From(parent.TxID(), 0, lockingScript.String(), 1000))
+	require.NoError(t, paymentTx.AddP2PKHOutputFromScript(lockingScript, 900))
+	require.NoError(t, paymentTx.FillAllInputs(context.Background(), &unlocker.Getter{PrivateKey: signer}))
+
+	ancestry := &spv.AncestryJSON{
+		TxID:  paymentTx.TxID(),
+		RawTx: paymentTx.String(),
+		Parents: map[string]*spv.AncestryJSON{
+			parent.TxID(): {
+				TxID:  parent.TxID(),
+				RawTx: parent.String(),
+			},
+		},
+	}
+	ancestryBytes, err := ancestry.Bytes()
+	require.NoError(t, err)
+
+	// re-parse so the verifier sees the tx as it arrives off the wire.
+	paymentTx, err = bt.NewTxFromString(paymentTx.String())
+	require.NoError(t, err)
+
+	return paymentTx, ancestryBytes
+}
+
+// TestVerifyPayment_Script tests that VerifyPayment executes input scripts against their parent outputs.
+func TestVerifyPayment_Script(t *testing.T) {
+	owner, err := bec.NewPrivateKey()
+	require.NoError(t, err)
+	other, err := bec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		signer *bec.PrivateKey
+		opts   []spv.VerifyOpt
+		expErr bool
+	}{
+		"correctly signed input passes": {
+			signer: owner,
+		},
+		"input signed by wrong key fails": {
+			signer: other,
+			expErr: true,
+		},
+		"input signed by wrong key fails with explicit flags": {
+			signer: other,
+			opts: []spv.VerifyOpt{
+				spv.VerifyScriptFlags(scriptflag.UTXOAfterGenesis | scriptflag.EnableSighashForkID),
+			},
+			expErr: true,
+		},
+		"input signed by wrong key passes if script check disabled": {
+			signer: other,
+			opts: []spv.VerifyOpt{
+				spv.NoVerifyScript(),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			paymentTx, ancestryBytes := newSignedPayment(t, owner, test.signer)
+
+			v, err := spv.NewPaymentVerifier(&mockBlockHeaderClient{}, spv.NoVerifyProofs())
+			require.NoError(t, err)
+
+			err = v.VerifyPayment(context.Background(), &spv.Payment{
+				PaymentTx: paymentTx,
+				Ancestry:  ancestryBytes,
+			}, test.opts...)
+			if !test.expErr {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, spv.ErrScriptVerificationFailed)
+			var scriptErr *spv.ScriptError
+			require.ErrorAs(t, err, &scriptErr)
+			require.Equal(t, paymentTx.TxID(), scriptErr.TxID)
+			require.Equal(t, 0, scriptErr.Vin)
+			require.Error(t, scriptErr.Err)
+		})
+	}
+}
+
+// feeQuotePerKB returns a fee quote charging sats per 1000 bytes for every fee type.
+func feeQuotePerKB(sats int) *bt.FeeQuote {
+	fq := bt.NewFeeQuote()
+	for _, ft := range []bt.FeeType{bt.FeeTypeStandard, bt.FeeTypeData} {
+		fq.AddQuote(ft, &bt.Fee{
+			FeeType:   ft,
+			MiningFee: bt.FeeUnit{Satoshis: sats, Bytes: 1000},
+			RelayFee:  bt.FeeUnit{Satoshis: sats, Bytes: 1000},
+		})
+	}
+	return fq
+}
+
+// TestVerifyPaymentReport_Beef tests the report of a BEEF payment.
+func TestVerifyPaymentReport_Beef(t *testing.T) {
+	beef, err := spv.NewBeefFromStr(brc62Hex)
+	require.NoError(t, err)
+	paymentTx := beef.Txs[1].Tx
+	paymentTxID := paymentTx.TxID()
+
+	t.Run("valid beef reports every check", func(t *testing.T) {
+		v, err := spv.NewPaymentVerifier(&mockRootHeightClient{roots: map[uint64]string{brc62Height: brc62Root}})
+		require.NoError(t, err)
+
+		report, err := v.VerifyPaymentReport(context.Background(), &spv.Payment{
+			PaymentTx: paymentTx,
+			Beef:      beef,
+		}, spv.VerifyFees(feeQuotePerKB(10)))
+		require.NoError(t, err)
+		require.True(t, report.Valid())
+		require.Equal(t, &spv.FeeReport{Paid: 2, Required: 1, Enough: true}, report.Fees)
+
+		require.Equal(t, paymentTxID, report.PaymentTxID)
+		require.Len(t, report.Txs, 2)
+
+		anchored := report.Txs[brc62AnchoredTxID]
+		require.NotNil(t, anchored)
+		require.Equal(t, spv.ProofValid, anchored.Proof)
+		require.Equal(t, uint64(brc62Height), anchored.BlockHeight)
+		require.NoError(t, anchored.Err)
+
+		payment := report.Txs[paymentTxID]
+		require.NotNil(t, payment)
+		require.Equal(t, spv.ProofNotSupplied, payment.Proof)
+		require.Len(t, payment.Inputs, 1)
+		require.True(t, payment.Inputs[0].Checked)
+		require.Equal(t, brc62AnchoredTxID, payment.Inputs[0].PreviousTxID)
+		require.NoError(t, payment.Inputs[0].Err)
+		require.NoError(t, payment.Err)
+	})
+
+	t.Run("fees below the fee quote are reported", func(t *testing.T) {
+		v, err := spv.NewPaymentVerifier(&mockRootHeightClient{roots: map[uint64]string{brc62Height: brc62Root}})
+		require.NoError(t, err)
+
+		report, err := v.VerifyPaymentReport(context.Background(), &spv.Payment{
+			PaymentTx: paymentTx,
+			Beef:      beef,
+		}, spv.VerifyFees(bt.NewFeeQuote()))
+		require.ErrorIs(t, err, spv.ErrFeePaidNotEnough)
+		require.False(t, report.Valid())
+		require.Equal(t, uint64(2), report.Fees.Paid)
+		require.Equal(t, uint64(9), report.Fees.Required)
+		require.False(t, report.Fees.Enough)
+		require.ErrorIs(t, report.Fees.Err, spv.ErrFeePaidNotEnough)
+		require.Equal(t, spv.ProofValid, report.Txs[brc62AnchoredTxID].Proof)
+	})
+
+	t.Run("invalid proof is reported and other checks still run", func(t *testing.T) {
+		v, err := spv.NewPaymentVerifier(&mockRootHeightClient{})
+		require.NoError(t, err)
+
+		report, err := v.VerifyPaymentReport(context.Background(), &spv.Payment{
+			PaymentTx: paymentTx,
+			Beef:      beef,
+		})
+		require.ErrorIs(t, err, spv.ErrInvalidProof)
+		require.False(t, report.Valid())
+		require.Nil(t, report.Fees)
+
+		require.Equal(t, spv.ProofInvalid, report.Txs[brc62AnchoredTxID].Proof)
+		require.ErrorIs(t, report.Txs[brc62AnchoredTxID].Err, spv.ErrInvalidProof)
+		require.True(t, report.Txs[paymentTxID].Inputs[0].Checked)
+		require.NoError(t, report.Txs[paymentTxID].Err)
+	})
+
+	t.Run("switched off checks are reported as not checked", func(t *testing.T) {
+		v, err := spv.NewPaymentVerifier(&mockRootHeightClient{})
+		require.NoError(t, err)
+
+		report, err := v.VerifyPaymentReport(context.Background(), &spv.Payment{
+			PaymentTx: paymentTx,
+			Beef:      beef,
+		}, spv.NoVerifySPV())
+		require.NoError(t, err)
+		require.True(t, report.Valid())
+		for _, tr := range report.Txs {
+			require.Equal(t, spv.ProofNotChecked, tr.Proof)
+			for _, ir := range tr.Inputs {
+				require.False(t, ir.Checked)
+			}
+		}
+	})
+}
+
+// TestVerifyPaymentReport_FeesDoNotModifyTx tests that checking fees leaves the payment tx untouched.
+func TestVerifyPaymentReport_FeesDoNotModifyTx(t *testing.T) {
+	p := loadPayment(t, "valid")
+	v, err := spv.NewPaymentVerifier(&mockBlockHeaderClient{}, spv.NoVerifyProofs(), spv.NoVerifyScript())
+	require.NoError(t, err)
+
+	report, err := v.VerifyPaymentReport(context.Background(), p, spv.VerifyFees(bt.NewFeeQuote()))
+	require.NoError(t, err)
+	require.True(t, report.Fees.Enough)
+	require.NotZero(t, report.Fees.Paid)
+	for _, input := range p.PaymentTx.Inputs {
+		require.Zero(t, input.PreviousTxSatoshis)
+		require.Nil(t, input.PreviousTxScript)
+	}
+}
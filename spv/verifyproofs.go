@@ -0,0 +1,94 @@
+package spv
+
+import (
+	"context"
+	"sync"
+)
+
+// proofResult is the outcome of checking the merkle proof or BUMP of a single tx.
+type proofResult struct {
+	status    ProofStatus
+	blockHash string
+	err       error
+}
+
+// checkProof verifies the merkle proof or BUMP anchoring the tx, which must have one of them.
+func (v *verifier) checkProof(ctx context.Context, a *ancestry, txID string) *proofResult {
+	if a.BUMP != nil {
+		if err := v.verifyBUMP(ctx, a.BUMP, txID); err != nil {
+			return &proofResult{status: ProofInvalid, err: err}
+		}
+		return &proofResult{status: ProofValid}
+	}
+
+	response, err := v.VerifyMerkleProof(ctx, a.Proof)
+	switch {
+	case err != nil || response == nil || !response.Valid:
+		err = ErrInvalidProof
+	case !response.proves(txID):
+		err = ErrTxIDMismatch
+	default:
+		return &proofResult{status: ProofValid, blockHash: response.BlockHash}
+	}
+	return &proofResult{status: ProofInvalid, err: err}
+}
+
+// verifyProofs checks the proof of every anchored tx in the ancestry using a pool of workers,
+// looking up each block header only once. It returns the result for each tx checked, and the
+// first failure found.
+//
+// If failFast is set, outstanding work is cancelled on the first failure, so txs may be missing
+// from the results.
+func (v *verifier) verifyProofs(ctx context.Context, aa map[[32]byte]*ancestry, workers int, failFast bool) (map[*ancestry]*proofResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cv := &verifier{bhc: newHeaderCache(v.bhc), opts: v.opts}
+	results := make(map[*ancestry]*proofResult, len(aa))
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan *ancestry)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				r := cv.checkProof(ctx, a, a.Tx.TxID())
+				mu.Lock()
+				results[a] = r
+				if r.err != nil && firstErr == nil {
+					firstErr = r.err
+					if failFast {
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+L:
+	for _, a := range aa {
+		if a.BUMP == nil && a.Proof == nil {
+			continue
+		}
+		select {
+		case jobs <- a:
+		case <-ctx.Done():
+			break L
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		// the caller's context was cancelled before any proof failed.
+		firstErr = ctx.Err()
+	}
+	return results, firstErr
+}
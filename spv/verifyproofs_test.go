@@ -0,0 +1,210 @@
+package spv_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsv-blockchain/go-bc"
+	"github.com/bsv-blockchain/go-bc/spv"
+	"github.com/bsv-blockchain/go-bc/testing/data"
+)
+
+// countingHeaderClient serves the fixture headers, counting the lookups of each block hash.
+type countingHeaderClient struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingHeaderClient) BlockHeader(_ context.Context, blockHash string) (*bc.BlockHeader, error) {
+	c.mu.Lock()
+	c.calls[blockHash]++
+	c.mu.Unlock()
+	bb, err := data.BlockHeaderData.Load(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return bc.NewBlockHeaderFromStr(string(bb[:160]))
+}
+
+// loadPayment returns the payment described by a spv verify fixture.
+func loadPayment(t *testing.T, file string) *spv.Payment {
+	t.Helper()
+	testData := struct {
+		Envelope *spv.AncestryJSON `json:"data"`
+	}{}
+	bb, err := data.SpvVerifyData.Load(file + ".json")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(bytes.NewBuffer(bb)).Decode(&testData))
+
+	ancestry, err := testData.Envelope.Bytes()
+	require.NoError(t, err)
+	paymentTx, err := bt.NewTxFromString(testData.Envelope.RawTx)
+	require.NoError(t, err)
+	return &spv.Payment{PaymentTx: paymentTx, Ancestry: ancestry}
+}
+
+func TestVerifyPayment_ProofsConcurrently(t *testing.T) {
+	tests := map[string]struct {
+		testFile string
+		expErr   error
+	}{
+		"valid ancestry passes": {
+			testFile: "valid",
+		},
+		"valid deep ancestry passes": {
+			testFile: "valid_deep",
+		},
+		"wrong merkle proof fails": {
+			testFile: "invalid_wrong_merkle_proof",
+			expErr:   spv.ErrInvalidProof,
+		},
+		"wrong merkle proof in deep ancestry fails": {
+			testFile: "invalid_deep_wrong_merkle_proof",
+			expErr:   spv.ErrTxIDMismatch,
+		},
+		"wrong merkle proof index in deep ancestry fails": {
+			testFile: "invalid_deep_merkle_proof_index",
+			expErr:   spv.ErrInvalidProof,
+		},
+		"ancestry without any proof fails": {
+			testFile: "invalid_missing_merkle_proof",
+			expErr:   spv.ErrProofOrInputMissing,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := loadPayment(t, test.testFile)
+			for _, workers := range []int{1, 2, 8} {
+				bhc := &countingHeaderClient{calls: map[string]int{}}
+				v, err := spv.NewPaymentVerifier(bhc, spv.VerifyProofsConcurrently(workers))
+				require.NoError(t, err)
+
+				err = v.VerifyPayment(context.Background(), p)
+				if test.expErr == nil {
+					require.NoError(t, err, "workers %d", workers)
+				} else {
+					require.EqualError(t, errors.Cause(err), test.expErr.Error(), "workers %d", workers)
+				}
+				if workers > 1 {
+					for hash, n := range bhc.calls {
+						require.Equal(t, 1, n, "header %s looked up %d times", hash, n)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyPaymentReport_ProofsConcurrently(t *testing.T) {
+	p := loadPayment(t, "valid_deep")
+	v, err := spv.NewPaymentVerifier(&countingHeaderClient{calls: map[string]int{}})
+	require.NoError(t, err)
+
+	serial, err := v.VerifyPaymentReport(context.Background(), p)
+	require.NoError(t, err)
+	concurrent, err := v.VerifyPaymentReport(context.Background(), p, spv.VerifyProofsConcurrently(4))
+	require.NoError(t, err)
+	require.Equal(t, serial, concurrent)
+}
+
+func TestVerifyPayment_ProofsConcurrentlyCancelsOnFailure(t *testing.T) {
+	p := loadPayment(t, "valid_deep")
+
+	// one block's lookup fails once another is in flight, which waits until it is cancelled.
+	const failingBlock = "0994eeb6386321c276177d52be4879ed4f8fedaa942cca6ecf18d66cf08962ef"
+	started := make(chan struct{}, 16)
+	cancelled := make(chan struct{}, 16)
+	bhc := &mockBlockHeaderClient{
+		blockHeaderFunc: func(ctx context.Context, hash string) (*bc.BlockHeader, error) {
+			if hash == failingBlock {
+				<-started
+				return nil, errors.New("header lookup failed")
+			}
+			started <- struct{}{}
+			select {
+			case <-ctx.Done():
+				cancelled <- struct{}{}
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil, errors.New("lookup was not cancelled")
+			}
+		},
+	}
+	v, err := spv.NewPaymentVerifier(bhc, spv.VerifyProofsConcurrently(4))
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = v.VerifyPayment(context.Background(), p)
+	require.ErrorIs(t, err, spv.ErrInvalidProof)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.NotEmpty(t, cancelled)
+}
+
+func TestVerifyPayment_CompositeProof(t *testing.T) {
+	newTx := func(prevTxID string) *bt.Tx {
+		tx := bt.NewTx()
+		require.NoError(t, tx.From(prevTxID, 0, "51", 1000))
+		tx.AddOutput(&bt.Output{Satoshis: 1000, LockingScript: tx.Inputs[0].PreviousTxScript})
+		return tx
+	}
+	parent := newTx("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+	paymentTx := newTx(parent.TxID())
+
+	// a block of six txs with the parent fifth.
+	txids := make([]*chainhash.Hash, 0, 6)
+	for i := 0; i < 6; i++ {
+		txids = append(txids, &chainhash.Hash{byte(i + 1)})
+	}
+	parentHash, err := chainhash.NewHashFromHex(parent.TxID())
+	require.NoError(t, err)
+	txids[4] = parentHash
+	bump, err := bc.NewBUMPFromMerkleTreeAndIndices(100, bc.BuildMerkleTreeStoreChainHash(txids), []uint64{1, 4, 5})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		proven []*chainhash.Hash
+		expErr error
+	}{
+		"parent proven after another tx": {
+			proven: []*chainhash.Hash{txids[1], txids[4], txids[5]},
+		},
+		"parent not proven": {
+			proven: []*chainhash.Hash{txids[1], txids[5]},
+			expErr: spv.ErrTxIDMismatch,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			proven := make([]string, 0, len(test.proven))
+			for _, h := range test.proven {
+				proven = append(proven, h.String())
+			}
+			proof, err := bc.NewCompositeMerkleProofFromBUMP(context.Background(), bump, proven, nil)
+			require.NoError(t, err)
+			ancestry, err := spv.TSCAncestriesJSON{{RawTx: parent.String(), Proof: proof}}.Bytes()
+			require.NoError(t, err)
+
+			v, err := spv.NewPaymentVerifier(&mockBlockHeaderClient{}, spv.NoVerifyScript())
+			require.NoError(t, err)
+			for _, workers := range []int{1, 2} {
+				err = v.VerifyPayment(context.Background(), &spv.Payment{PaymentTx: paymentTx, Ancestry: ancestry},
+					spv.VerifyProofsConcurrently(workers))
+				if test.expErr == nil {
+					require.NoError(t, err, "workers %d", workers)
+				} else {
+					require.ErrorIs(t, err, test.expErr, "workers %d", workers)
+				}
+			}
+		})
+	}
+}